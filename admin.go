@@ -0,0 +1,64 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <oci.h>
+*/
+import "C"
+
+// StartupMode modifies how Startup starts the instance.
+type StartupMode C.ub4
+
+// The available startup modes.
+const (
+	StartupDefault  StartupMode = C.OCI_DEFAULT
+	StartupForce    StartupMode = C.OCI_DBSTARTUPFLAG_FORCE    // shut down a running instance (abort) first
+	StartupRestrict StartupMode = C.OCI_DBSTARTUPFLAG_RESTRICT // only allow users with RESTRICTED SESSION
+)
+
+// ShutdownMode determines how Shutdown deals with connected users.
+type ShutdownMode C.ub4
+
+// The available shutdown modes.
+const (
+	ShutdownDefault            ShutdownMode = C.OCI_DEFAULT                        // wait for users to disconnect
+	ShutdownTransactional      ShutdownMode = C.OCI_DBSHUTDOWN_TRANSACTIONAL       // wait for transactions to finish
+	ShutdownTransactionalLocal ShutdownMode = C.OCI_DBSHUTDOWN_TRANSACTIONAL_LOCAL // same, for local transactions only
+	ShutdownImmediate          ShutdownMode = C.OCI_DBSHUTDOWN_IMMEDIATE           // roll back and disconnect everybody
+	ShutdownAbort              ShutdownMode = C.OCI_DBSHUTDOWN_ABORT               // stop the instance right away
+	ShutdownFinal              ShutdownMode = C.OCI_DBSHUTDOWN_FINAL               // second call, after dismounting
+)
+
+// Startup starts the database instance, without mounting or opening the database. The
+// session must be connected with WithPrivilege(PrivilegeSysDBA) or PrivilegeSysOper and
+// WithPrelimAuth. Afterwards, close the session, connect again without WithPrelimAuth and
+// run "alter database mount" and "alter database open".
+func (sess *Session) Startup(mode StartupMode) error {
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	err := checkError(
+		C.OCIDBStartup(sess.svc, sess.err, nil, C.OCI_DEFAULT, C.ub4(mode)), sess.err)
+
+	return processError(err)
+}
+
+// Shutdown stops the database instance. Except with ShutdownAbort, this takes two calls:
+// Shutdown with one of the other modes, then "alter database close normal" and
+// "alter database dismount", and finally Shutdown(ShutdownFinal). The session must be
+// connected with WithPrivilege(PrivilegeSysDBA) or PrivilegeSysOper.
+func (sess *Session) Shutdown(mode ShutdownMode) error {
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	err := checkError(
+		C.OCIDBShutdown(sess.svc, sess.err, nil, C.ub4(mode)), sess.err)
+
+	return processError(err)
+}
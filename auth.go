@@ -0,0 +1,138 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <stdlib.h>
+#include <oci.h>
+*/
+import "C"
+import (
+	"context"
+	"errors"
+	"unsafe"
+)
+
+// AcquireAs gets a session logged in as user instead of the pool user. The pool must
+// be heterogeneous (see WithHeterogeneous); sessions are only reused for the same user.
+func (pool *Pool) AcquireAs(user, password string) (*Session, error) {
+
+	if !pool.heterogeneous() {
+		return nil, errors.New("AcquireAs requires a heterogeneous pool")
+	}
+	if user == "" || password == "" {
+		return nil, errors.New("AcquireAs requires a username and password")
+	}
+
+	auth, err := pool.newAuthInfo(user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer ociHandleFree((unsafe.Pointer)(auth), htypeAuthInfo)
+
+	if err := pool.setDRCP(auth); err != nil {
+		return nil, processError(err)
+	}
+
+	sess, _, err := pool.acquire(context.Background(), acquireParams{auth: auth})
+	return sess, err
+}
+
+// AcquireProxy gets a session for proxyUser, connected through the pool user, so that the
+// database records (and audits) the end user while the application only needs the
+// credentials of the pool user. proxyUser must have been granted CONNECT THROUGH the pool
+// user. The session is started with roles enabled, if any are given, instead of the
+// default roles of proxyUser. The pool must be heterogeneous (see WithHeterogeneous).
+func (pool *Pool) AcquireProxy(proxyUser string, roles ...string) (*Session, error) {
+
+	if !pool.heterogeneous() {
+		return nil, errors.New("AcquireProxy requires a heterogeneous pool")
+	}
+	if proxyUser == "" {
+		return nil, errors.New("AcquireProxy requires a proxy user")
+	}
+
+	auth := (*C.OCIAuthInfo)(ociHandleAlloc((unsafe.Pointer)(pool.env.env), htypeAuthInfo))
+	defer ociHandleFree((unsafe.Pointer)(auth), htypeAuthInfo)
+
+	if err := setCredential((unsafe.Pointer)(auth), htypeAuthInfo, proxyUser, attrUsername, pool.err); err != nil {
+		return nil, processError(err)
+	}
+
+	if err := pool.setDRCP(auth); err != nil {
+		return nil, processError(err)
+	}
+
+	if len(roles) > 0 {
+		freeRoles, err := setClientRoles(auth, roles, pool.err)
+		defer freeRoles()
+		if err != nil {
+			return nil, processError(err)
+		}
+	}
+
+	sess, _, err := pool.acquire(context.Background(), acquireParams{auth: auth, proxy: true})
+	return sess, err
+}
+
+// setClientRoles sets the initial roles of the session to be created with auth. OCI wants
+// an array of string pointers, which has to be allocated in C memory; the returned function
+// frees it again, and must only be called once the session has been created.
+func setClientRoles(auth *C.OCIAuthInfo, roles []string, errh *C.OCIError) (func(), *OciError) {
+
+	ptrSize := unsafe.Sizeof((*C.OraText)(nil))
+	array := C.malloc(C.size_t(uintptr(len(roles)) * ptrSize))
+	ptrs := (*[1 << 20]*C.OraText)(array)[:len(roles):len(roles)]
+
+	for i, role := range roles {
+		ptrs[i] = (*C.OraText)(unsafe.Pointer(C.CString(role)))
+	}
+
+	free := func() {
+		for _, p := range ptrs {
+			C.free(unsafe.Pointer(p))
+		}
+		C.free(array)
+	}
+
+	return free, ociAttrSet((unsafe.Pointer)(auth), htypeAuthInfo, array, C.ub4(len(roles)), attrInitialClientRoles, errh)
+}
+
+// heterogeneous reports whether sessions can be acquired with credentials other than
+// the pool's own.
+func (pool *Pool) heterogeneous() bool {
+	return pool.cfg.Heterogeneous || pool.cfg.ExternalAuth
+}
+
+// newAuthInfo allocates an authentication info handle for OCISessionGet holding the
+// given credentials. OCI keeps its own copy of them.
+func (pool *Pool) newAuthInfo(user, password string) (*C.OCIAuthInfo, error) {
+
+	auth := (*C.OCIAuthInfo)(ociHandleAlloc((unsafe.Pointer)(pool.env.env), htypeAuthInfo))
+
+	err := setCredential((unsafe.Pointer)(auth), htypeAuthInfo, user, attrUsername, pool.err)
+	if err == nil {
+		err = setCredential((unsafe.Pointer)(auth), htypeAuthInfo, password, attrPassword, pool.err)
+	}
+
+	if err != nil {
+		ociHandleFree((unsafe.Pointer)(auth), htypeAuthInfo)
+		return nil, processError(err)
+	}
+
+	return auth, nil
+}
+
+// setCredential sets a username or password attribute of an auth info or session
+// handle, and zeroes the copy of the value that had to be made to pass it to OCI.
+func setCredential(handle unsafe.Pointer, htype ociHandleType, value string, attr ociAttrType, errh *C.OCIError) *OciError {
+	b := []byte(value)
+	defer zeroBytes(b)
+	return ociAttrSet(handle, htype, unsafe.Pointer(&b[0]), C.ub4(len(b)), attr, errh)
+}
+
+// zeroBytes overwrites b, so that credentials don't linger in memory.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
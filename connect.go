@@ -0,0 +1,226 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <oci.h>
+*/
+import "C"
+import (
+	"context"
+	"fmt"
+	"unsafe"
+)
+
+// Connect opens a dedicated (non-pooled) session to the database described by
+// connectString (see ParseDSN), with the privilege it names, if any. The same options
+// as for OpenPool can be given; the ones that only make sense for a pool, such as the
+// session counts, are ignored. Close the session with Close (or Release, which does the
+// same for standalone sessions).
+//
+// If the password is about to expire, Session.Warning returns a *PasswordExpiringWarning.
+func Connect(connectString string, opts ...PoolOption) (*Session, error) {
+
+	dsn, err := ParseDSN(connectString)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := DefaultPoolConfig()
+	cfg.Username = dsn.Username
+	cfg.Password = dsn.Password
+	cfg.Database = dsn.Database()
+	cfg.ExternalAuth = dsn.ExternalAuth
+	cfg.Privilege = dsn.Privilege
+	cfg.DRCP = dsn.Pooled()
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := cfg.validateLogin(); err != nil {
+		return nil, fmt.Errorf("connect: %v", err)
+	}
+	if _, ok := privilegeModes[cfg.Privilege]; !ok {
+		return nil, fmt.Errorf("connect: invalid privilege %v", cfg.Privilege)
+	}
+	if cfg.PrelimAuth && cfg.Privilege != PrivilegeSysDBA && cfg.Privilege != PrivilegeSysOper {
+		return nil, fmt.Errorf("connect: PrelimAuth requires SYSDBA or SYSOPER")
+	}
+
+	env, err := envOrDefault(cfg.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	sess := &Session{env: env, obs: cfg.Observer, trc: cfg.Tracer, ctx: context.Background()}
+	sess.guard.strict = cfg.CheckConcurrency
+	warning, e := sess.logon(&cfg)
+	if e != nil {
+		sess.free()
+		return nil, e
+	}
+	sess.warning = warning
+
+	if cfg.OnNewSession != nil {
+		if e := cfg.OnNewSession(sess); e != nil {
+			sess.Close()
+			return nil, fmt.Errorf("new session init: %v", e)
+		}
+	}
+
+	return sess, nil
+}
+
+// privilegeModes maps privileges to OCISessionBegin modes.
+var privilegeModes = map[Privilege]C.ub4{
+	PrivilegeNone:      C.OCI_DEFAULT,
+	PrivilegeSysDBA:    C.OCI_SYSDBA,
+	PrivilegeSysOper:   C.OCI_SYSOPER,
+	PrivilegeSysASM:    C.OCI_SYSASM,
+	PrivilegeSysBackup: C.OCI_SYSBKP,
+	PrivilegeSysDG:     C.OCI_SYSDGD,
+	PrivilegeSysKM:     C.OCI_SYSKMT,
+}
+
+// attach allocates the handles of a standalone session and attaches to the server.
+func (sess *Session) attach(db string) error {
+
+	sess.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(sess.env.env), htypeError))
+	sess.srv = (*C.OCIServer)(ociHandleAlloc((unsafe.Pointer)(sess.env.env), htypeServer))
+	sess.svc = (*C.OCISvcCtx)(ociHandleAlloc((unsafe.Pointer)(sess.env.env), htypeSvcCtx))
+	sess.ses = (*C.OCISession)(ociHandleAlloc((unsafe.Pointer)(sess.env.env), htypeSession))
+
+	database, databaseLen := oraText([]byte(db))
+
+	err := checkError(
+		C.OCIServerAttach(sess.srv, sess.err, database, C.sb4(databaseLen), C.OCI_DEFAULT), sess.err)
+	if e := processError(err); e != nil {
+		return e
+	}
+	sess.attached = true
+
+	err = ociAttrSet((unsafe.Pointer)(sess.svc), htypeSvcCtx, (unsafe.Pointer)(sess.srv), 0, attrServer, sess.err)
+	return processError(err)
+}
+
+// logon attaches to the server and begins a session, as described by cfg. The
+// warning result is set if the password is about to expire.
+func (sess *Session) logon(cfg *PoolConfig) (warning error, e error) {
+
+	if e := sess.attach(cfg.Database); e != nil {
+		return nil, e
+	}
+
+	var creds C.ub4 = C.OCI_CRED_EXT
+	if !cfg.ExternalAuth {
+		creds = C.OCI_CRED_RDBMS
+		err := setCredential((unsafe.Pointer)(sess.ses), htypeSession, cfg.Username, attrUsername, sess.err)
+		if err == nil {
+			err = setCredential((unsafe.Pointer)(sess.ses), htypeSession, cfg.Password, attrPassword, sess.err)
+		}
+		if e := processError(err); e != nil {
+			return nil, e
+		}
+	}
+
+	if cfg.usesDRCP() {
+		if e := processError(setDRCP((unsafe.Pointer)(sess.ses), htypeSession, cfg, sess.err)); e != nil {
+			return nil, e
+		}
+	}
+
+	var mode C.ub4 = C.OCI_STMT_CACHE
+	if cfg.PrelimAuth {
+		mode = C.OCI_PRELIM_AUTH
+	}
+	mode |= privilegeModes[cfg.Privilege]
+
+	err := checkError(
+		C.OCISessionBegin(sess.svc, sess.err, sess.ses, creds, mode), sess.err)
+	if warning, e = loginError(err); e != nil {
+		return nil, e
+	}
+	sess.begun = true
+
+	err = ociAttrSet((unsafe.Pointer)(sess.svc), htypeSvcCtx, (unsafe.Pointer)(sess.ses), 0, attrSession, sess.err)
+	if e := processError(err); e != nil {
+		return nil, e
+	}
+
+	// there is no statement cache without a full login
+	if cfg.StatementCacheSize > 0 && !cfg.PrelimAuth {
+		err = ociAttrSetUB4((unsafe.Pointer)(sess.svc), htypeSvcCtx, cfg.StatementCacheSize, attrStmtcachesize, sess.err)
+		if e := processError(err); e != nil {
+			return nil, e
+		}
+	}
+
+	return warning, nil
+}
+
+// Close ends the session. A standalone session (see Connect) is logged off after any
+// uncommitted work is rolled back; a pooled session is released to its pool.
+func (sess *Session) Close() error {
+
+	if sess.pool != nil {
+		return sess.Release()
+	}
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	sess.exit()
+
+	sess.closeStatements()
+
+	// don't leave the fate of an open transaction to OCISessionEnd
+	var rslt error
+	if inTxn, err := sess.inTransaction(); err != nil {
+		rslt = err
+	} else if inTxn {
+		rslt = sess.Rollback()
+	}
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	if e := sess.free(); rslt == nil {
+		rslt = e
+	}
+
+	return rslt
+}
+
+// free logs off a standalone session as far as it got logged on, and frees its handles.
+func (sess *Session) free() error {
+
+	var rslt error
+
+	if sess.begun {
+		err := checkError(C.OCISessionEnd(sess.svc, sess.err, sess.ses, C.OCI_DEFAULT), sess.err)
+		rslt = processError(err)
+	}
+
+	if sess.attached {
+		err := checkError(C.OCIServerDetach(sess.srv, sess.err, C.OCI_DEFAULT), sess.err)
+		if e := processError(err); rslt == nil {
+			rslt = e
+		}
+	}
+
+	ociHandleFree((unsafe.Pointer)(sess.ses), htypeSession)
+	ociHandleFree((unsafe.Pointer)(sess.svc), htypeSvcCtx)
+	ociHandleFree((unsafe.Pointer)(sess.srv), htypeServer)
+	ociHandleFree((unsafe.Pointer)(sess.err), htypeError)
+
+	sess.ses = nil
+	sess.svc = nil
+	sess.srv = nil
+	sess.err = nil
+	sess.begun = false
+	sess.attached = false
+
+	return rslt
+}
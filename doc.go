@@ -6,7 +6,23 @@ Go, I find that the database/sql interface rather lacking, really for any databa
 especially Oracle and the features it has. I would like to see Go v2 come up with a
 better database interface.
 
+# Concurrency
 
+A Pool is safe for concurrent use; any number of goroutines can Acquire and Release
+sessions at the same time. A Session, with its statements and result sets, may be shared
+between goroutines as well, but OCI only runs one call on a session at a time: calls from
+different goroutines wait for each other. Sessions of a pool created WithConcurrencyCheck
+return ErrConcurrentUse instead of waiting, to find sessions that are shared by mistake.
+Note that a Session is not a unit of isolation: goroutines sharing one share its
+transaction too.
+
+# Transactions
+
+Session.Begin starts a transaction and returns a Tx to commit or roll it back, and to set
+savepoints in. Session.InTx runs a function in a transaction, committing it if the function
+succeeds and retrying it if the transaction fails to serialize (ORA-08177).
+Session.StartGlobal starts a branch of a global transaction, to take part in two-phase
+commit coordinated by an external transaction manager.
 
 */
 package oci
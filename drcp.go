@@ -0,0 +1,68 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <oci.h>
+*/
+import "C"
+import (
+	"unsafe"
+)
+
+// Purity determines whether a DRCP session can be one that was used before.
+type Purity C.ub4
+
+// The available purities. PurityDefault is PuritySelf for pooled sessions and
+// PurityNew for standalone ones.
+const (
+	PurityDefault Purity = C.OCI_ATTR_PURITY_DEFAULT
+	PurityNew     Purity = C.OCI_ATTR_PURITY_NEW  // a session that has not been used before
+	PuritySelf    Purity = C.OCI_ATTR_PURITY_SELF // a session left behind by the same connection class
+)
+
+// usesDRCP reports whether there are DRCP settings to pass to OCI: the DSN names a
+// pooled server, or a connection class or purity was given.
+func (cfg *PoolConfig) usesDRCP() bool {
+	return cfg.DRCP || cfg.ConnectionClass != "" || cfg.Purity != PurityDefault
+}
+
+func (pool *Pool) usesDRCP() bool {
+	return pool.cfg.usesDRCP()
+}
+
+// setDRCP sets the connection class and purity of the pool on auth.
+func (pool *Pool) setDRCP(auth *C.OCIAuthInfo) *OciError {
+	return setDRCP((unsafe.Pointer)(auth), htypeAuthInfo, &pool.cfg, pool.err)
+}
+
+// setDRCP sets the connection class and purity of cfg on handle, an auth info handle
+// for pooled sessions or the session handle of a standalone one.
+func setDRCP(handle unsafe.Pointer, htype ociHandleType, cfg *PoolConfig, errh *C.OCIError) *OciError {
+
+	if cfg.ConnectionClass != "" {
+		err := ociAttrSetString(handle, htype, cfg.ConnectionClass, attrConnectionClass, errh)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Purity != PurityDefault {
+		return ociAttrSetUB4(handle, htype, uint32(cfg.Purity), attrPurity, errh)
+	}
+
+	return nil
+}
+
+// setPoolAuth gives the pool an auth info handle carrying the DRCP settings, which OCI
+// uses for every session it gets without an auth info handle of its own. It must be
+// called before the pool is created.
+func (pool *Pool) setPoolAuth() *OciError {
+
+	pool.auth = (*C.OCIAuthInfo)(ociHandleAlloc((unsafe.Pointer)(pool.env.env), htypeAuthInfo))
+
+	if err := pool.setDRCP(pool.auth); err != nil {
+		return err
+	}
+
+	return ociAttrSet((unsafe.Pointer)(pool.pool), htypeSessionPool, (unsafe.Pointer)(pool.auth), 0, attrSessPoolAuth, pool.err)
+}
@@ -0,0 +1,219 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <stdlib.h>
+#include <oci.h>
+*/
+import "C"
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// EnvMode is a set of flags for creating an OCI environment.
+type EnvMode C.ub4
+
+// The available environment modes; combine them with |.
+const (
+	EnvThreaded EnvMode = C.OCI_THREADED // handles may be used from several threads
+	EnvObject   EnvMode = C.OCI_OBJECT   // object types (needed for ANYDATA, XMLTYPE, ...)
+	EnvEvents   EnvMode = C.OCI_EVENTS   // FAN events, see WithEvents
+)
+
+// defaultEnvMode is used when EnvConfig.Mode is zero.
+const defaultEnvMode = EnvThreaded | EnvObject | EnvEvents
+
+// EnvConfig describes an OCI environment.
+type EnvConfig struct {
+	Charset  string  // client character set, such as "AL32UTF8"; "" means AL32UTF8
+	NCharset string  // client national character set, used for NCHAR data; "" means UTF16
+	Mode     EnvMode // zero means EnvThreaded | EnvObject | EnvEvents
+}
+
+// CharsetNLSLang can be used as EnvConfig.Charset or NCharset to take the character set
+// from the NLS_LANG environment variable, as OCI does by default. The result then
+// depends on the host, which is why it isn't the default here.
+const CharsetNLSLang = "NLS_LANG"
+
+// character set ids used by default: AL32UTF8, and UTF-16 for NCHAR data
+const (
+	charsetAL32UTF8 = 873
+	charsetUTF16    = C.OCI_UTF16ID
+)
+
+// Environment is an OCI environment: the character sets and modes shared by the pools
+// and sessions created in it. Most programs can use the default environment, which is
+// created when it is first needed; see PoolConfig.Environment for using another one.
+type Environment struct {
+	env *C.OCIEnv
+	err *C.OCIError
+	cfg EnvConfig
+
+	charsetID  uint16 // as reported by OCI, whatever EnvConfig asked for
+	ncharsetID uint16
+	charWidth  int32 // maximum bytes per character of the client character set
+}
+
+var (
+	defaultEnvOnce sync.Once
+	defaultEnv     *Environment
+	defaultEnvErr  error
+)
+
+// DefaultEnvironment returns the environment used by pools and sessions that don't
+// name one, creating it the first time.
+func DefaultEnvironment() (*Environment, error) {
+	defaultEnvOnce.Do(func() {
+		defaultEnv, defaultEnvErr = NewEnvironment(EnvConfig{})
+	})
+	return defaultEnv, defaultEnvErr
+}
+
+// mustDefaultEnvironment returns the default environment, for the values (numbers, raws)
+// that aren't tied to a session. It panics if the environment can't be created, as those
+// values are of no use without OCI anyway.
+func mustDefaultEnvironment() *Environment {
+	env, err := DefaultEnvironment()
+	if err != nil {
+		panic(err)
+	}
+	return env
+}
+
+// envOrDefault returns env, or the default environment if env is nil.
+func envOrDefault(env *Environment) (*Environment, error) {
+	if env != nil {
+		return env, nil
+	}
+	return DefaultEnvironment()
+}
+
+// NewEnvironment creates an OCI environment as described by cfg.
+func NewEnvironment(cfg EnvConfig) (*Environment, error) {
+
+	if cfg.Mode == 0 {
+		cfg.Mode = defaultEnvMode
+	}
+
+	charset, ncharset, err := charsetIDs(cfg.Charset, cfg.NCharset)
+	if err != nil {
+		return nil, err
+	}
+
+	rslt := &Environment{cfg: cfg}
+
+	errcode := C.OCIEnvNlsCreate(&rslt.env, C.ub4(cfg.Mode), nil, nil, nil, nil, 0, nil, charset, ncharset)
+	if errcode != C.OCI_SUCCESS {
+		if rslt.env != nil {
+			C.OCIHandleFree(unsafe.Pointer(rslt.env), C.OCI_HTYPE_ENV)
+		}
+		return nil, fmt.Errorf("OCIEnvNlsCreate failed with errcode = %d", errcode)
+	}
+
+	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(rslt.env), htypeError))
+
+	// remember the character sets OCI ended up with, in case they came from NLS_LANG
+	rslt.charsetID, _ = rslt.CharsetID()
+	rslt.ncharsetID, _ = rslt.NCharsetID()
+
+	var width C.sb4
+	if C.OCINlsNumericInfoGet(unsafe.Pointer(rslt.env), rslt.err, &width, C.OCI_NLS_CHARSET_MAXBYTESZ) == C.OCI_SUCCESS && width > 0 {
+		rslt.charWidth = int32(width)
+	} else {
+		rslt.charWidth = 4 // the widest there is, AL32UTF8
+	}
+
+	return rslt, nil
+}
+
+// charsetIDs looks up the ids of the named character sets. An empty name gets the
+// default, and CharsetNLSLang id 0, which makes OCI use NLS_LANG. Looking up other names
+// needs an environment of its own.
+func charsetIDs(charset, ncharset string) (C.ub2, C.ub2, error) {
+
+	id, nid := C.ub2(charsetAL32UTF8), C.ub2(charsetUTF16)
+
+	known := func(name string, id *C.ub2) bool {
+		switch strings.ToUpper(name) {
+		case "":
+		case CharsetNLSLang:
+			*id = 0
+		case "AL32UTF8":
+			*id = charsetAL32UTF8
+		case "UTF16":
+			*id = charsetUTF16
+		default:
+			return false
+		}
+		return true
+	}
+
+	knownCharset, knownNCharset := known(charset, &id), known(ncharset, &nid)
+	if knownCharset && knownNCharset {
+		return id, nid, nil
+	}
+
+	var env *C.OCIEnv
+	if errcode := C.OCIEnvNlsCreate(&env, C.OCI_DEFAULT, nil, nil, nil, nil, 0, nil, 0, 0); errcode != C.OCI_SUCCESS {
+		return 0, 0, fmt.Errorf("OCIEnvNlsCreate failed with errcode = %d", errcode)
+	}
+	defer C.OCIHandleFree(unsafe.Pointer(env), C.OCI_HTYPE_ENV)
+
+	lookup := func(name string) (C.ub2, error) {
+		cname := C.CString(name)
+		defer C.free(unsafe.Pointer(cname))
+		id := C.OCINlsCharSetNameToId(unsafe.Pointer(env), (*C.OraText)(unsafe.Pointer(cname)))
+		if id == 0 {
+			return 0, fmt.Errorf("unknown character set %q", name)
+		}
+		return id, nil
+	}
+
+	var err error
+	if !knownCharset {
+		if id, err = lookup(charset); err != nil {
+			return 0, 0, err
+		}
+	}
+	if !knownNCharset {
+		if nid, err = lookup(ncharset); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return id, nid, nil
+}
+
+// CharsetID returns the id of the client character set of the environment, as
+// reported by OCI. AL32UTF8 is 873.
+func (env *Environment) CharsetID() (uint16, error) {
+	v, err := ociAttrGetUB2((unsafe.Pointer)(env.env), htypeEnv, attrEnvCharsetID, env.err)
+	return v, processError(err)
+}
+
+// NCharsetID returns the id of the client national character set of the environment,
+// as reported by OCI. UTF-16 is 1000.
+func (env *Environment) NCharsetID() (uint16, error) {
+	v, err := ociAttrGetUB2((unsafe.Pointer)(env.env), htypeEnv, attrEnvNcharsetID, env.err)
+	return v, processError(err)
+}
+
+// nationalUTF16 reports whether national character set data is exchanged as UTF-16.
+func (env *Environment) nationalUTF16() bool {
+	return env.ncharsetID == charsetUTF16
+}
+
+// Close frees the environment. Every pool and session created in it must be closed first.
+func (env *Environment) Close() error {
+	ociHandleFree((unsafe.Pointer)(env.err), htypeError)
+	err := C.OCIHandleFree(unsafe.Pointer(env.env), C.OCI_HTYPE_ENV)
+	env.err = nil
+	env.env = nil
+	if err != C.OCI_SUCCESS {
+		return fmt.Errorf("OCIHandleFree(HTYPE_ENV) failed with errcode = %d", err)
+	}
+	return nil
+}
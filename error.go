@@ -24,6 +24,65 @@ import (
 	"unsafe"
 )
 
+// OraError is an error reported by Oracle. Code is the number from the "ORA-nnnnn" prefix
+// of the (first) message.
+type OraError struct {
+	Code    int
+	Message string
+}
+
+func (err *OraError) Error() string {
+	return err.Message
+}
+
+// ErrorCode returns the ORA error number of err, or zero if err is not an Oracle error.
+func ErrorCode(err error) int {
+	var oraErr *OraError
+	if errors.As(err, &oraErr) {
+		return oraErr.Code
+	}
+	return 0
+}
+
+var (
+	// ErrSessionClosed is returned when a session is used after it was released or closed.
+	ErrSessionClosed = errors.New("oci: session is closed")
+
+	// ErrStatementClosed is returned when a statement, or a result set of it, is used
+	// after it was released. Once its session is released, ErrSessionClosed is returned.
+	ErrStatementClosed = errors.New("oci: statement is closed")
+
+	// ErrTruncated is returned by Fetch when a column value didn't fit its buffer
+	// (ORA-01406). The error names the column.
+	ErrTruncated = errors.New("oci: fetched column value truncated")
+
+	// ErrTxDone is returned when a transaction is used after it was committed or rolled
+	// back, including by its context being done.
+	ErrTxDone = errors.New("oci: transaction has already been committed or rolled back")
+)
+
+// PasswordExpiringWarning is returned by Session.Warning or Pool.Warning when the
+// password the session or pool logged in with is in its grace period (ORA-28002).
+// Change the password with ChangePassword or Session.ChangePassword before it expires.
+type PasswordExpiringWarning struct {
+	Message string
+}
+
+func (w *PasswordExpiringWarning) Error() string {
+	return w.Message
+}
+
+const oraPasswordWillExpire = 28002
+
+// loginError is processError for calls that log in: an ORA-28002 warning is returned as
+// a *PasswordExpiringWarning in warning, rather than printed.
+func loginError(err *OciError) (warning error, e error) {
+	if err != nil && !err.IsError() && err.code == oraPasswordWillExpire {
+		return &PasswordExpiringWarning{Message: err.Warning()}, nil
+	}
+	return nil, processError(err)
+}
+
 type OciError struct {
 	code int32
 	err  error
@@ -46,6 +105,19 @@ func (err *OciError) Error() string {
 	return err.err.Error()
 }
 
+// Unwrap returns the error carried by err, so that errors.Is and errors.As see through it.
+func (err *OciError) Unwrap() error {
+	return err.err
+}
+
+// asError returns the error (not the warning) carried by err, without printing anything.
+func (err *OciError) asError() error {
+	if err == nil || err.err == nil {
+		return nil
+	}
+	return err.err
+}
+
 func processError(err *OciError) error {
 	if err == nil {
 		return nil
@@ -82,7 +154,7 @@ func checkError(errval C.sword, errhndl *C.OCIError) (result *OciError) {
 	if errval == C.OCI_SUCCESS_WITH_INFO {
 		result.inf = rsltstr
 	} else {
-		result.err = errors.New(rsltstr)
+		result.err = &OraError{Code: int(eCode), Message: rsltstr}
 	}
 
 	return
@@ -0,0 +1,3 @@
+module github.com/djbckr/ocigo
+
+go 1.21
@@ -0,0 +1,36 @@
+package oci
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrConcurrentUse is returned, when the session was created with WithConcurrencyCheck,
+// by a call on a session (or one of its statements or result sets) that is already
+// busy with a call from another goroutine.
+var ErrConcurrentUse = errors.New("oci: concurrent use of a session")
+
+// guard serialises the calls into OCI made for one session. OCI only allows a service
+// context to be used by one thread at a time; rather than leaving that to the user, a
+// session (with its statements and result sets) can be shared between goroutines, and
+// calls simply wait for each other. In strict mode they fail with ErrConcurrentUse
+// instead, to find code that shares a session by accident.
+type guard struct {
+	mu     sync.Mutex
+	strict bool
+}
+
+func (g *guard) enter() error {
+	if !g.strict {
+		g.mu.Lock()
+		return nil
+	}
+	if !g.mu.TryLock() {
+		return ErrConcurrentUse
+	}
+	return nil
+}
+
+func (g *guard) exit() {
+	g.mu.Unlock()
+}
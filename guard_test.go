@@ -0,0 +1,248 @@
+package oci
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// backend stands in for OCI: it records how many calls are in progress at once.
+type backend struct {
+	active  int32
+	maxSeen int32
+	calls   int32
+}
+
+func (b *backend) call() {
+	n := atomic.AddInt32(&b.active, 1)
+	for {
+		seen := atomic.LoadInt32(&b.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&b.maxSeen, seen, n) {
+			break
+		}
+	}
+	atomic.AddInt32(&b.calls, 1)
+	atomic.AddInt32(&b.active, -1)
+}
+
+func TestGuardSerialises(t *testing.T) {
+
+	var g guard
+	var b backend
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if err := g.enter(); err != nil {
+					t.Error(err)
+					return
+				}
+				b.call()
+				g.exit()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if b.maxSeen != 1 {
+		t.Errorf("%d calls in progress at once, want 1", b.maxSeen)
+	}
+	if b.calls != 8000 {
+		t.Errorf("%d calls made, want 8000", b.calls)
+	}
+}
+
+func TestGuardStrict(t *testing.T) {
+
+	g := guard{strict: true}
+
+	if err := g.enter(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- g.enter() }()
+
+	if err := <-done; err != ErrConcurrentUse {
+		t.Errorf("enter on a busy guard returned %v, want ErrConcurrentUse", err)
+	}
+
+	g.exit()
+
+	if err := g.enter(); err != nil {
+		t.Errorf("enter after exit: %v", err)
+	}
+	g.exit()
+}
+
+func TestPoolCountersConcurrent(t *testing.T) {
+
+	var c poolCounters
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.acquired(0)
+				c.statementCache(j%2 == 0)
+				c.released()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var s PoolStats
+	c.snapshot(&s)
+	if s.Acquires != 800 || s.Releases != 800 || s.StmtCacheHits != 400 || s.StmtCacheMisses != 400 {
+		t.Errorf("unexpected counts %+v", s)
+	}
+}
+
+// The tests below call Session and Statement methods from many goroutines. Without a
+// database they can only get as far as the guard, which is the part shared by all calls.
+
+func TestSessionClosedConcurrent(t *testing.T) {
+
+	sess := &Session{} // released: no service context
+	stmt := &Statement{stmtHandles: &stmtHandles{}, ses: sess}
+
+	calls := []func() error{
+		sess.Commit,
+		sess.Rollback,
+		func() error { return sess.Ping(context.Background()) },
+		func() error { _, err := sess.Prepare("select 1 from dual"); return err },
+		stmt.Execute,
+		func() error { _, err := stmt.Query(); return err },
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for _, call := range calls {
+					if err := call(); err != ErrSessionClosed {
+						t.Errorf("call on a released session returned %v, want ErrSessionClosed", err)
+						return
+					}
+				}
+				if err := stmt.Release(true); err != nil {
+					t.Errorf("Release of a statement of a released session: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSessionStrictConcurrent(t *testing.T) {
+
+	sess := &Session{}
+	sess.guard.strict = true
+	stmt := &Statement{stmtHandles: &stmtHandles{}, ses: sess}
+
+	// another goroutine is in the middle of a call
+	if err := sess.guard.enter(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 3)
+	go func() { done <- sess.Commit() }()
+	go func() { done <- stmt.Execute() }()
+	go func() { _, err := sess.Prepare("select 1 from dual"); done <- err }()
+
+	for i := 0; i < 3; i++ {
+		if err := <-done; err != ErrConcurrentUse {
+			t.Errorf("call on a busy session returned %v, want ErrConcurrentUse", err)
+		}
+	}
+
+	sess.guard.exit()
+
+	if err := sess.Commit(); err != ErrSessionClosed {
+		t.Errorf("Commit once the session is no longer busy returned %v, want ErrSessionClosed", err)
+	}
+}
+
+func TestStatementFinalizerBusySession(t *testing.T) {
+
+	sess := &Session{}
+	stmt := &Statement{stmtHandles: &stmtHandles{}, ses: sess}
+
+	// another goroutine is in the middle of a long call
+	if err := sess.guard.enter(); err != nil {
+		t.Fatal(err)
+	}
+	defer sess.guard.exit()
+
+	done := make(chan struct{})
+	go func() {
+		stmtFinalizer(stmt)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the statement finalizer waited for a busy session")
+	}
+
+	sess.orphanMu.Lock()
+	defer sess.orphanMu.Unlock()
+	if len(sess.orphans) != 1 || sess.orphans[0] != stmt.stmtHandles {
+		t.Errorf("orphans = %v, want the statement handles", sess.orphans)
+	}
+}
+
+func TestStatementCacheStatsConcurrent(t *testing.T) {
+
+	sess := &Session{}
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if j%2 == 0 {
+					sess.statementCacheHit()
+				} else {
+					sess.statementCacheMiss()
+				}
+				sess.StatementCacheStats()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits, misses := sess.StatementCacheStats(); hits != 400 || misses != 400 {
+		t.Errorf("StatementCacheStats() = %d, %d; want 400, 400", hits, misses)
+	}
+}
+
+func TestPoolCountersKeepBuckets(t *testing.T) {
+
+	var c poolCounters
+	c.init()
+
+	saved := AcquireWaitBuckets
+	defer func() { AcquireWaitBuckets = saved }()
+	AcquireWaitBuckets = append(AcquireWaitBuckets[:len(AcquireWaitBuckets):len(AcquireWaitBuckets)], time.Minute)
+
+	c.acquired(time.Hour)
+
+	var s PoolStats
+	c.snapshot(&s)
+	if len(s.WaitBuckets) != len(saved) || len(s.WaitHistogram) != len(saved)+1 || s.WaitHistogram[len(saved)] != 1 {
+		t.Errorf("histogram changed shape: buckets %v, counts %v", s.WaitBuckets, s.WaitHistogram)
+	}
+}
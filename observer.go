@@ -0,0 +1,28 @@
+package oci
+
+import (
+	"time"
+)
+
+// Observer is notified about statement executions and fetches, typically to feed a
+// metrics system (see the ocimetrics package). The key identifies the SQL text; it is
+// the same key used for the statement cache (see Statement.Key).
+//
+// Observer methods are called synchronously on the goroutine making the database
+// call, so they must be quick and safe for concurrent use.
+type Observer interface {
+	// Executed is called after every execution of a statement, including queries.
+	Executed(key string, elapsed time.Duration, err error)
+
+	// Fetched is called when the fetch of a result set ends, with the number of rows
+	// fetched: when the rows run out, a fetch fails, or the statement is queried again
+	// or released before that.
+	Fetched(key string, rows int, err error)
+}
+
+// WithObserver registers an Observer for every session acquired from the pool.
+func WithObserver(observer Observer) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Observer = observer
+	}
+}
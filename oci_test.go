@@ -3,6 +3,7 @@ package oci_test
 import (
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,7 +20,14 @@ func TestOCI(t *testing.T) {
 	}
 
 	fmt.Println("Creating Pool")
-	pool, err := oci.CreatePool(connstring, 1, 5, 1)
+	pool, err := oci.OpenPool(connstring,
+		oci.WithSessions(1, 5, 1),
+		oci.WithSessionInit(func(ses *oci.Session) error {
+			execSql(ses, t, "alter session set nls_timestamp_format='YYYY-MM-DD HH24:MI:SS.FF4'")
+			execSql(ses, t, "alter session set NLS_DATE_FORMAT='YYYY-MM-DD HH24:MI:SS'")
+			execSql(ses, t, "alter session set nls_timestamp_tz_format='YYYY-MM-DD HH24:MI:SSXFF TZR'")
+			return nil
+		}))
 	checkerr(t, err)
 	defer pool.Destroy()
 
@@ -62,10 +70,6 @@ create table foo (
   "XmlType"      XMLTYPE
 )`)
 
-	execSql(ses, t, "alter session set nls_timestamp_format='YYYY-MM-DD HH24:MI:SS.FF4'")
-	execSql(ses, t, "alter session set NLS_DATE_FORMAT='YYYY-MM-DD HH24:MI:SS'")
-	execSql(ses, t, "alter session set nls_timestamp_tz_format='YYYY-MM-DD HH24:MI:SSXFF TZR'")
-
 	execSql(ses, t, `insert into foo values (
 		anydata.convertVarchar2('hello world'), 1.13, 1.13, hextoraw('deadbeef'), 'こんにちは', 'helloworld', 'Mary had a little lamb...',
 		sysdate, 1.13, numtodsinterval(1.27777777, 'day'), numtoyminterval(1.234, 'year'), 'Mary had a little lamb...', 'Mary had a little lamb...',
@@ -120,6 +124,66 @@ create table foo (
 
 }
 
+// TestSharedSession uses one session from several goroutines at once, which the guard
+// around calls into OCI has to serialise.
+func TestSharedSession(t *testing.T) {
+
+	connstring := os.Getenv("CONNECT_STRING")
+	if connstring == "" {
+		t.Skip("CONNECT_STRING is not set")
+	}
+
+	pool, err := oci.OpenPool(connstring, oci.WithSessions(1, 1, 1))
+	checkerr(t, err)
+	defer pool.Destroy()
+
+	ses, err := pool.Acquire()
+	checkerr(t, err)
+	defer ses.Release()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				stmt, err := ses.Prepare("select 'x' from dual")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				rs, err := stmt.Query()
+				if err != nil {
+					t.Error(err)
+					stmt.Release(true)
+					return
+				}
+				for {
+					fetched, err := rs.Fetch()
+					if err != nil {
+						t.Error(err)
+						break
+					}
+					if !fetched {
+						break
+					}
+				}
+				if err := stmt.Release(true); err != nil {
+					t.Error(err)
+				}
+				if err := ses.Commit(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits, misses := ses.StatementCacheStats(); hits+misses != 160 {
+		t.Errorf("StatementCacheStats() = %d, %d; want 160 lookups", hits, misses)
+	}
+}
+
 func checkerr(t *testing.T, e error) {
 	if e != nil {
 		panic(e)
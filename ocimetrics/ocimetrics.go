@@ -0,0 +1,181 @@
+/*
+Package ocimetrics exports metrics about oci pools and statements to Prometheus.
+It lives in its own package so that the oci package itself doesn't depend on Prometheus.
+
+	collector := ocimetrics.NewCollector("myapp")
+	prometheus.MustRegister(collector)
+
+	pool, err := oci.OpenPool(dsn, oci.WithObserver(collector))
+	...
+	collector.AddPool("orders", pool)
+*/
+package ocimetrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/djbckr/ocigo"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector for pool statistics and an oci.Observer
+// for statement execute latency, fetched rows and ORA error codes.
+type Collector struct {
+	mu    sync.Mutex
+	pools map[string]*oci.Pool
+
+	poolOpen     *prometheus.Desc
+	poolBusy     *prometheus.Desc
+	poolAcquires *prometheus.Desc
+	poolTimeouts *prometheus.Desc
+	poolFailures *prometheus.Desc
+	poolWait     *prometheus.Desc
+	stmtCache    *prometheus.Desc
+
+	execLatency *prometheus.HistogramVec
+	fetchRows   *prometheus.CounterVec
+	oraErrors   *prometheus.CounterVec
+}
+
+// NewCollector creates a Collector whose metric names start with namespace.
+func NewCollector(namespace string) *Collector {
+
+	poolLabels := []string{"pool"}
+
+	return &Collector{
+		pools: make(map[string]*oci.Pool),
+
+		poolOpen: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "sessions_open"),
+			"Sessions currently open in the pool.", poolLabels, nil),
+		poolBusy: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "sessions_busy"),
+			"Sessions currently acquired from the pool.", poolLabels, nil),
+		poolAcquires: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "acquires_total"),
+			"Sessions successfully acquired from the pool.", poolLabels, nil),
+		poolTimeouts: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "acquire_timeouts_total"),
+			"Acquire calls that gave up waiting for a free session.", poolLabels, nil),
+		poolFailures: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "acquire_failures_total"),
+			"Acquire calls that failed for reasons other than a timeout.", poolLabels, nil),
+		poolWait: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "acquire_wait_seconds"),
+			"Time spent waiting in successful Acquire calls.", poolLabels, nil),
+		stmtCache: prometheus.NewDesc(prometheus.BuildFQName(namespace, "oci_pool", "statement_cache_lookups_total"),
+			"Statement cache lookups by Prepare, by result (hit or miss).", []string{"pool", "result"}, nil),
+
+		execLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "oci_statement",
+			Name:      "execute_seconds",
+			Help:      "Statement execute latency by SQL key.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"sql_key"}),
+
+		fetchRows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "oci_statement",
+			Name:      "fetched_rows_total",
+			Help:      "Rows fetched by SQL key.",
+		}, []string{"sql_key"}),
+
+		oraErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "oci",
+			Name:      "errors_total",
+			Help:      "Errors returned by the database, by ORA error code.",
+		}, []string{"code"}),
+	}
+}
+
+// AddPool starts reporting the statistics of pool under the given name.
+func (c *Collector) AddPool(name string, pool *oci.Pool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pools[name] = pool
+}
+
+// RemovePool stops reporting the pool registered under name. Call it before destroying the pool.
+func (c *Collector) RemovePool(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pools, name)
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.poolOpen
+	ch <- c.poolBusy
+	ch <- c.poolAcquires
+	ch <- c.poolTimeouts
+	ch <- c.poolFailures
+	ch <- c.poolWait
+	ch <- c.stmtCache
+	c.execLatency.Describe(ch)
+	c.fetchRows.Describe(ch)
+	c.oraErrors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+
+	c.mu.Lock()
+	pools := make(map[string]*oci.Pool, len(c.pools))
+	for name, pool := range c.pools {
+		pools[name] = pool
+	}
+	c.mu.Unlock()
+
+	for name, pool := range pools {
+		stats, err := pool.Stats()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(c.poolOpen, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.poolOpen, prometheus.GaugeValue, float64(stats.Open), name)
+		ch <- prometheus.MustNewConstMetric(c.poolBusy, prometheus.GaugeValue, float64(stats.Busy), name)
+		ch <- prometheus.MustNewConstMetric(c.poolAcquires, prometheus.CounterValue, float64(stats.Acquires), name)
+		ch <- prometheus.MustNewConstMetric(c.poolTimeouts, prometheus.CounterValue, float64(stats.Timeouts), name)
+		ch <- prometheus.MustNewConstMetric(c.poolFailures, prometheus.CounterValue, float64(stats.Failures), name)
+		ch <- waitHistogram(c.poolWait, stats, name)
+		ch <- prometheus.MustNewConstMetric(c.stmtCache, prometheus.CounterValue, float64(stats.StmtCacheHits), name, "hit")
+		ch <- prometheus.MustNewConstMetric(c.stmtCache, prometheus.CounterValue, float64(stats.StmtCacheMisses), name, "miss")
+	}
+
+	c.execLatency.Collect(ch)
+	c.fetchRows.Collect(ch)
+	c.oraErrors.Collect(ch)
+}
+
+// waitHistogram converts the per-bucket counts of PoolStats to a cumulative Prometheus histogram.
+func waitHistogram(desc *prometheus.Desc, stats oci.PoolStats, name string) prometheus.Metric {
+
+	buckets := make(map[float64]uint64, len(stats.WaitBuckets))
+	var cumulative uint64
+
+	for i, bound := range stats.WaitBuckets {
+		cumulative += stats.WaitHistogram[i]
+		buckets[bound.Seconds()] = cumulative
+	}
+
+	return prometheus.MustNewConstHistogram(desc, stats.Acquires, stats.WaitTotal.Seconds(), buckets, name)
+}
+
+// Executed implements oci.Observer.
+func (c *Collector) Executed(key string, elapsed time.Duration, err error) {
+	c.execLatency.WithLabelValues(key).Observe(elapsed.Seconds())
+	c.countError(err)
+}
+
+// Fetched implements oci.Observer.
+func (c *Collector) Fetched(key string, rows int, err error) {
+	if rows > 0 {
+		c.fetchRows.WithLabelValues(key).Add(float64(rows))
+	}
+	c.countError(err)
+}
+
+func (c *Collector) countError(err error) {
+	if code := oci.ErrorCode(err); code != 0 {
+		c.oraErrors.WithLabelValues(strconv.Itoa(code)).Inc()
+	}
+}
@@ -0,0 +1,11 @@
+module github.com/djbckr/ocigo/ocitrace
+
+go 1.21
+
+require (
+	github.com/djbckr/ocigo v0.0.0-00010101000000-000000000000
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+replace github.com/djbckr/ocigo => ../
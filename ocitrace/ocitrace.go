@@ -0,0 +1,93 @@
+/*
+Package ocitrace implements oci.Tracer on top of OpenTelemetry. It lives in its own
+package so that the oci package itself doesn't depend on OpenTelemetry.
+
+	pool, err := oci.OpenPool(dsn, oci.WithTracer(ocitrace.New(otel.Tracer("orders"))))
+	...
+	sess, err := pool.AcquireContext(r.Context())
+*/
+package ocitrace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/djbckr/ocigo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer adapts an OpenTelemetry trace.Tracer to oci.Tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// New returns an oci.Tracer that creates client spans with tracer.
+func New(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// Start implements oci.Tracer.
+func (t *Tracer) Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, oci.Span) {
+
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, keyValue(k, v))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(kvs...))
+
+	return ctx, &otelSpan{span: span}
+}
+
+// TraceID implements oci.Tracer.
+func (t *Tracer) TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// otelSpan adapts trace.Span to oci.Span.
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(keyValue(key, value))
+}
+
+func (s *otelSpan) AddEvent(name string, attrs map[string]interface{}) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, keyValue(k, v))
+	}
+	s.span.AddEvent(name, trace.WithAttributes(kvs...))
+}
+
+func (s *otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
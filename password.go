@@ -0,0 +1,97 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <oci.h>
+*/
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// ChangePassword changes the password of the user in connectString (see ParseDSN) to
+// newPassword. It works for expired passwords too (ORA-28001), which make every other
+// way of logging in fail.
+func ChangePassword(connectString, newPassword string) error {
+
+	dsn, err := ParseDSN(connectString)
+	if err != nil {
+		return err
+	}
+
+	if dsn.ExternalAuth || dsn.Username == "" || dsn.Password == "" {
+		return errors.New("ChangePassword requires a username and password")
+	}
+	if newPassword == "" {
+		return errors.New("ChangePassword requires a new password")
+	}
+
+	env, err := DefaultEnvironment()
+	if err != nil {
+		return err
+	}
+
+	sess := &Session{env: env}
+	defer sess.free()
+
+	if e := sess.attach(dsn.Database()); e != nil {
+		return e
+	}
+
+	// OCIPasswordChange logs in (OCI_AUTH) with the session handle on the service context
+	ociErr := ociAttrSet((unsafe.Pointer)(sess.svc), htypeSvcCtx, (unsafe.Pointer)(sess.ses), 0, attrSession, sess.err)
+	if e := processError(ociErr); e != nil {
+		return e
+	}
+
+	if e := sess.changePassword(dsn.Username, dsn.Password, newPassword, C.OCI_AUTH); e != nil {
+		return e
+	}
+	sess.begun = true
+
+	return nil
+}
+
+// ChangePassword changes the password of the user the session is logged in as.
+func (sess *Session) ChangePassword(oldPassword, newPassword string) error {
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	if newPassword == "" {
+		return errors.New("ChangePassword requires a new password")
+	}
+
+	user, err := ociAttrGetString((unsafe.Pointer)(sess.ses), htypeSession, attrUsername, sess.err)
+	if e := processError(err); e != nil {
+		return e
+	}
+
+	return sess.changePassword(user, oldPassword, newPassword, C.OCI_DEFAULT)
+}
+
+func (sess *Session) changePassword(user, oldPassword, newPassword string, mode C.ub4) error {
+
+	userBytes := []byte(user)
+	oldBytes := []byte(oldPassword)
+	newBytes := []byte(newPassword)
+	defer zeroBytes(oldBytes)
+	defer zeroBytes(newBytes)
+
+	userText, userLen := oraText(userBytes)
+	oldText, oldLen := oraText(oldBytes)
+	newText, newLen := oraText(newBytes)
+
+	err := checkError(
+		C.OCIPasswordChange(
+			sess.svc, sess.err,
+			userText, userLen,
+			oldText, oldLen,
+			newText, newLen,
+			mode), sess.err)
+
+	return processError(err)
+}
@@ -0,0 +1,425 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PoolConfig describes everything needed to create a session pool. Use NewPool to
+// create a pool directly from a PoolConfig, or OpenPool to start from
+// DefaultPoolConfig and adjust it with PoolOption functions.
+type PoolConfig struct {
+	Username string // database user the pool sessions log in as
+	Password string
+	Database string // EZConnect string, TNS alias or connect descriptor
+
+	// Environment the pool (or standalone session) is created in; nil means the
+	// default environment, see DefaultEnvironment.
+	Environment *Environment
+
+	// ExternalAuth logs sessions in with credentials held outside the application, such
+	// as an Oracle Wallet secure external password store or OS authentication, instead
+	// of Username and Password. External authentication pools are always heterogeneous.
+	ExternalAuth bool
+
+	// Privilege is an administrative privilege such as SYSDBA to log in with. It is
+	// only valid for Connect; pool sessions can't be privileged.
+	Privilege Privilege
+
+	// PrelimAuth makes a preliminary connection, the only kind possible to an idle
+	// instance, to start it with Session.Startup. It requires Privilege SYSDBA or
+	// SYSOPER, and is only valid for Connect.
+	PrelimAuth bool
+
+	MinSessions int // sessions opened when the pool is created, and kept open
+	MaxSessions int // upper bound on the number of open sessions
+	Increment   int // sessions opened at a time when the pool needs to grow
+
+	Timeout     time.Duration // idle sessions are closed after this long; zero means never
+	GetMode     AcquireMode   // what Acquire does when every session is busy
+	MaxLifetime time.Duration // sessions older than this are closed on release; zero means no limit
+	WaitTimeout time.Duration // how long Acquire waits in AcquireModeWait; zero means forever; needs Oracle Client 12.2
+
+	StatementCacheSize uint32 // statements cached per session; zero keeps the OCI default
+
+	// Heterogeneous pools allow sessions to be acquired with credentials other
+	// than the ones the pool was created with.
+	Heterogeneous bool
+
+	// Events enables runtime load balancing driven by FAN (Fast Application
+	// Notification) events. When false the pool is created with OCI_SPC_NO_RLB.
+	Events bool
+
+	// Observer, if set, is notified about statement executions and fetches.
+	Observer Observer
+
+	// Tracer, if set, creates spans around Acquire and the database calls made by sessions.
+	Tracer Tracer
+
+	// RollbackOnRelease rolls back any uncommitted work when a session is released,
+	// instead of leaving the open transaction to the next user of the session.
+	RollbackOnRelease bool
+
+	// ResetSession, if set, is called by Session.Release before the session goes back to
+	// the pool. Use it to undo per-user state such as the current schema or NLS settings.
+	ResetSession SessionResetFunc
+
+	// OnNewSession, if set, is called once for every physical session the pool opens,
+	// the first time it is acquired. Use it for setup that survives reuse, such as
+	// alter session statements. If it returns an error, the session is dropped and
+	// Acquire fails.
+	OnNewSession SessionInitFunc
+
+	// ConnectionClass and Purity are used with Database Resident Connection Pooling
+	// (DRCP), when Database names a pooled server. Sessions are only shared between
+	// pools with the same connection class; Purity decides whether a session may be
+	// one left behind by an earlier user of the class.
+	ConnectionClass string
+	Purity          Purity
+
+	// DRCP is set when Database names a pooled server (see DSN.Pooled). Pooled sessions
+	// are then asked for with an explicit purity: PurityNew if Purity says so, PuritySelf
+	// otherwise. Set ConnectionClass as well to share sessions between pools.
+	DRCP bool
+
+	// CheckConcurrency makes calls on a session that is busy with a call from another
+	// goroutine fail with ErrConcurrentUse, instead of waiting for that call to finish.
+	// It is meant for finding code that shares a session by accident.
+	CheckConcurrency bool
+
+	// TagFixup, if set, is called by AcquireTagged when it gets a session without the
+	// requested tag.
+	TagFixup TagFixupFunc
+}
+
+// poolConfigFields is PoolConfig without its methods, for printing it.
+type poolConfigFields PoolConfig
+
+// redacted returns the fields of cfg with the password replaced, if there is one.
+func (cfg PoolConfig) redacted() poolConfigFields {
+	if cfg.Password != "" {
+		cfg.Password = redactedPassword
+	}
+	return poolConfigFields(cfg)
+}
+
+// String returns the configuration with the password redacted, so it is safe to log.
+func (cfg PoolConfig) String() string {
+	return fmt.Sprintf("%+v", cfg.redacted())
+}
+
+// GoString is like String, for the %#v verb.
+func (cfg PoolConfig) GoString() string {
+	return "oci.PoolConfig" + strings.TrimPrefix(fmt.Sprintf("%#v", cfg.redacted()), "oci.poolConfigFields")
+}
+
+// TagFixupFunc brings a session into the state described by requestedTag, after
+// AcquireTagged returned a session with actualTag ("" for a new or untagged session)
+// instead. If it returns an error, the session is dropped and AcquireTagged fails.
+type TagFixupFunc func(sess *Session, requestedTag, actualTag string) error
+
+// WithTagFixup registers a function that fixes up sessions that don't have the requested tag.
+func WithTagFixup(fixup TagFixupFunc) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.TagFixup = fixup
+	}
+}
+
+// SessionInitFunc prepares a newly opened session for use.
+type SessionInitFunc func(*Session) error
+
+// WithSessionInit registers a function that is run once on every new physical session.
+func WithSessionInit(init SessionInitFunc) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.OnNewSession = init
+	}
+}
+
+// SessionResetFunc restores a session to a known state before it is reused. If it
+// returns an error, the session is dropped from the pool rather than reused.
+type SessionResetFunc func(*Session) error
+
+// WithRollbackOnRelease rolls back uncommitted work when sessions are released.
+func WithRollbackOnRelease() PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.RollbackOnRelease = true
+	}
+}
+
+// WithSessionReset registers a function that resets sessions before they go back to the pool.
+func WithSessionReset(reset SessionResetFunc) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.ResetSession = reset
+	}
+}
+
+// PoolOption adjusts a PoolConfig. Options are applied in order by OpenPool.
+type PoolOption func(*PoolConfig)
+
+// DefaultPoolConfig returns the configuration used by OpenPool before any options are applied.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MinSessions: 1,
+		MaxSessions: 10,
+		Increment:   1,
+		Timeout:     90 * time.Second,
+		GetMode:     AcquireModeWait,
+	}
+}
+
+// WithEnvironment creates the pool, or standalone session, in env instead of the default environment.
+func WithEnvironment(env *Environment) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Environment = env
+	}
+}
+
+// WithCredentials sets the username and password the pool logs in with.
+func WithCredentials(username, password string) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Username = username
+		cfg.Password = password
+	}
+}
+
+// WithExternalAuth makes the pool use external authentication instead of a username and
+// password. It is implied by a connect string of the form "/@database".
+func WithExternalAuth() PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Username = ""
+		cfg.Password = ""
+		cfg.ExternalAuth = true
+	}
+}
+
+// WithPrivilege sets the administrative privilege Connect logs in with.
+func WithPrivilege(privilege Privilege) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Privilege = privilege
+	}
+}
+
+// WithPrelimAuth makes Connect open a preliminary connection, for starting an idle instance.
+func WithPrelimAuth() PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.PrelimAuth = true
+	}
+}
+
+// WithDRCP sets the connection class and purity for Database Resident Connection Pooling.
+func WithDRCP(connectionClass string, purity Purity) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.ConnectionClass = connectionClass
+		cfg.Purity = purity
+	}
+}
+
+// WithConcurrencyCheck makes concurrent use of a session fail with ErrConcurrentUse.
+func WithConcurrencyCheck() PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.CheckConcurrency = true
+	}
+}
+
+// WithSessions sets the minimum, maximum and increment session counts.
+func WithSessions(minSessions, maxSessions, incrStep int) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.MinSessions = minSessions
+		cfg.MaxSessions = maxSessions
+		cfg.Increment = incrStep
+	}
+}
+
+// WithTimeout sets how long an idle session may stay in the pool.
+func WithTimeout(timeout time.Duration) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Timeout = timeout
+	}
+}
+
+// WithGetMode sets what Acquire does when every session is busy.
+func WithGetMode(mode AcquireMode) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.GetMode = mode
+	}
+}
+
+// WithMaxLifetime sets the maximum age of a pooled session.
+func WithMaxLifetime(lifetime time.Duration) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.MaxLifetime = lifetime
+	}
+}
+
+// WithWaitTimeout sets how long Acquire waits for a free session in AcquireModeWait.
+// Oracle Client 12.2 or later is needed; with an older client NewPool fails.
+func WithWaitTimeout(timeout time.Duration) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.WaitTimeout = timeout
+	}
+}
+
+// WithStatementCacheSize sets the number of statements cached by each session.
+func WithStatementCacheSize(size uint32) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.StatementCacheSize = size
+	}
+}
+
+// WithHeterogeneous creates a pool whose sessions may use different credentials.
+func WithHeterogeneous() PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Heterogeneous = true
+	}
+}
+
+// WithEvents enables FAN events and runtime load balancing for the pool.
+func WithEvents() PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Events = true
+	}
+}
+
+// Validate checks the configuration for problems that would otherwise surface as OCI errors
+// (or worse) when the pool is created.
+func (cfg *PoolConfig) Validate() error {
+
+	if err := cfg.validateLogin(); err != nil {
+		return fmt.Errorf("pool config: %v", err)
+	}
+	if cfg.Privilege != PrivilegeNone || cfg.PrelimAuth {
+		return errors.New("pool config: pool sessions cannot be privileged")
+	}
+	if cfg.MinSessions < 1 {
+		return errors.New("pool config: MinSessions must be 1 or more")
+	}
+	if cfg.MaxSessions < 1 {
+		return errors.New("pool config: MaxSessions must be 1 or more")
+	}
+	if cfg.Increment < 1 {
+		return errors.New("pool config: Increment must be 1 or more")
+	}
+	if cfg.MinSessions > cfg.MaxSessions {
+		return fmt.Errorf("pool config: MinSessions (%d) is greater than MaxSessions (%d)", cfg.MinSessions, cfg.MaxSessions)
+	}
+	if cfg.Timeout < 0 || cfg.MaxLifetime < 0 || cfg.WaitTimeout < 0 {
+		return errors.New("pool config: durations must not be negative")
+	}
+
+	switch cfg.GetMode {
+	case AcquireModeWait:
+	case AcquireModeNoWait, AcquireModeForce:
+		if cfg.WaitTimeout != 0 {
+			return errors.New("pool config: WaitTimeout requires GetMode AcquireModeWait")
+		}
+	default:
+		return fmt.Errorf("pool config: unknown GetMode %d", cfg.GetMode)
+	}
+
+	if cfg.WaitTimeout != 0 && attrSessPoolWaitTimeout == 0 {
+		return errors.New("pool config: WaitTimeout requires Oracle Client 12.2 or later")
+	}
+
+	return nil
+}
+
+// validateLogin checks the part of the configuration that Connect uses as well.
+func (cfg *PoolConfig) validateLogin() error {
+
+	if cfg.Database == "" {
+		return errors.New("Database is required")
+	}
+	if cfg.ExternalAuth {
+		if cfg.Username != "" || cfg.Password != "" {
+			return errors.New("Username and Password must be empty with ExternalAuth")
+		}
+	} else {
+		if cfg.Username == "" {
+			return errors.New("Username is required")
+		}
+		if cfg.Password == "" {
+			return errors.New("Password is required")
+		}
+	}
+
+	return nil
+}
+
+// applyDSNPoolParams copies the pool parameters of a URL style DSN into cfg.
+func applyDSNPoolParams(d *DSN, cfg *PoolConfig) error {
+
+	for key, value := range d.PoolParams {
+
+		switch key {
+		case "poolMin", "poolMax", "poolIncrement", "poolStmtCacheSize":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("dsn: %s must be a non-negative integer, not %q", key, value)
+			}
+			switch key {
+			case "poolMin":
+				cfg.MinSessions = n
+			case "poolMax":
+				cfg.MaxSessions = n
+			case "poolIncrement":
+				cfg.Increment = n
+			case "poolStmtCacheSize":
+				cfg.StatementCacheSize = uint32(n)
+			}
+
+		case "poolTimeout", "poolMaxLifetime", "poolWaitTimeout":
+			d, err := parseDSNDuration(value)
+			if err != nil {
+				return fmt.Errorf("dsn: %s: %v", key, err)
+			}
+			switch key {
+			case "poolTimeout":
+				cfg.Timeout = d
+			case "poolMaxLifetime":
+				cfg.MaxLifetime = d
+			case "poolWaitTimeout":
+				cfg.WaitTimeout = d
+			}
+
+		case "poolConnectionClass":
+			cfg.ConnectionClass = value
+
+		case "poolPurity":
+			switch strings.ToLower(value) {
+			case "default":
+				cfg.Purity = PurityDefault
+			case "new":
+				cfg.Purity = PurityNew
+			case "self":
+				cfg.Purity = PuritySelf
+			default:
+				return fmt.Errorf("dsn: poolPurity must be default, new or self, not %q", value)
+			}
+
+		case "poolGetMode":
+			switch strings.ToLower(value) {
+			case "wait":
+				cfg.GetMode = AcquireModeWait
+			case "nowait":
+				cfg.GetMode = AcquireModeNoWait
+			case "force", "forceget":
+				cfg.GetMode = AcquireModeForce
+			default:
+				return fmt.Errorf("dsn: poolGetMode must be wait, nowait or force, not %q", value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseDSNDuration accepts either a Go duration ("90s", "5m") or a plain number of seconds.
+func parseDSNDuration(value string) (time.Duration, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,36 @@
+package oci_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/djbckr/ocigo"
+)
+
+func TestPoolConfigRedactsPassword(t *testing.T) {
+
+	cfg := oci.DefaultPoolConfig()
+	cfg.Username, cfg.Password, cfg.Database = "scott", "tiger", "dbhost/orclpdb"
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		for _, v := range []interface{}{cfg, &cfg} {
+			out := fmt.Sprintf(format, v)
+			if strings.Contains(out, "tiger") {
+				t.Errorf("%s of %T shows the password: %s", format, v, out)
+			}
+			if !strings.Contains(out, "scott") {
+				t.Errorf("%s of %T lost the username: %s", format, v, out)
+			}
+		}
+	}
+
+	if out := fmt.Sprintf("%#v", cfg); !strings.HasPrefix(out, "oci.PoolConfig{") {
+		t.Errorf("%%#v = %s, want it to start with oci.PoolConfig{", out)
+	}
+
+	// the configuration itself keeps the password
+	if cfg.Password != "tiger" {
+		t.Errorf("Password = %q after printing, want tiger", cfg.Password)
+	}
+}
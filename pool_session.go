@@ -3,104 +3,258 @@ package oci
 /*
 #cgo pkg-config: oci
 #include <oci.h>
+
+// context key and value marking sessions that have been through PoolConfig.OnNewSession
+static ub1 ocigoInitKey[] = "ocigo.session.init";
+static const ub1 ocigoInitKeyLen = sizeof(ocigoInitKey) - 1;
+static ub1 ocigoInitDone = 1;
 */
 import "C"
 import (
+	"context"
 	"errors"
 	"fmt"
-	"regexp"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 )
 
-// package global OCI environment and error handles
-var (
-	genv *C.OCIEnv
-	gerr *C.OCIError
-)
-
 // Pool is an opaque structure that manages a connection pool to an Oracle database.
 type Pool struct {
 	pool        *C.OCISPool // session pool handle
 	err         *C.OCIError // pool error handle
 	poolName    *C.OraText  // name of pool, assigned by OCI
 	poolNameLen C.ub4
+	auth        *C.OCIAuthInfo // DRCP settings for sessions acquired without credentials
+	env         *Environment
 	username    []byte
 	password    []byte
 	database    []byte
+	cfg         PoolConfig
+	counters    poolCounters
+	warning     error // login warning of the pool user, see Warning
 }
 
 // CreatePool initializes a connection to a database and returns a Pool structure.
+// It is shorthand for OpenPool(connectString, WithSessions(minSessions, maxSessions, incrStep)).
 func CreatePool(connectString string, minSessions, maxSessions, incrStep int) (*Pool, error) {
+	return OpenPool(connectString, WithSessions(minSessions, maxSessions, incrStep))
+}
+
+// OpenPool parses connectString (see ParseDSN), applies any pool parameters it carries and then
+// opts on top of DefaultPoolConfig, and creates the pool.
+func OpenPool(connectString string, opts ...PoolOption) (*Pool, error) {
 
-	// validate inputs...
-	if minSessions < 1 {
-		panic("minSessions must be 1 or more")
+	dsn, err := ParseDSN(connectString)
+	if err != nil {
+		return nil, err
 	}
-	if maxSessions < 1 {
-		panic("maxSessions must be 1 or more")
+
+	if dsn.Privilege != PrivilegeNone {
+		return nil, fmt.Errorf("pool sessions cannot log in as %v", dsn.Privilege)
 	}
-	if incrStep < 1 {
-		panic("incrStep must be 1 or more")
+
+	cfg := DefaultPoolConfig()
+	cfg.Username = dsn.Username
+	cfg.Password = dsn.Password
+	cfg.Database = dsn.Database()
+	cfg.ExternalAuth = dsn.ExternalAuth
+	cfg.DRCP = dsn.Pooled()
+
+	if err := applyDSNPoolParams(dsn, &cfg); err != nil {
+		return nil, err
 	}
 
-	re := regexp.MustCompile(`^(.*?)\/(.*)@(.*)$`)
-	lst := re.FindStringSubmatch(connectString)
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
-	if len(lst) != 4 {
-		return nil, errors.New(`Connect String must be of the format: "username/password@<host/dbname> or <TNS-name>" (without quotes, <>)`)
+	return NewPool(cfg)
+}
+
+// NewPool validates cfg and creates a session pool from it. If the password of the pool
+// user is about to expire, Warning returns a *PasswordExpiringWarning.
+func NewPool(cfg PoolConfig) (*Pool, error) {
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	env, err := envOrDefault(cfg.Environment)
+	if err != nil {
+		return nil, err
 	}
 
 	// create pool object
 	rslt := &Pool{
-		username: []byte(lst[1]),
-		password: []byte(lst[2]),
-		database: []byte(lst[3])}
+		env:      env,
+		username: []byte(cfg.Username),
+		password: []byte(cfg.Password),
+		database: []byte(cfg.Database),
+		cfg:      cfg}
 
-	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(genv), htypeError))
-	rslt.pool = (*C.OCISPool)(ociHandleAlloc((unsafe.Pointer)(genv), htypeSessionPool))
+	rslt.counters.init()
 
-	// set pool inactive timeout for 90 seconds
-	var timeout C.ub4 = 90
+	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(env.env), htypeError))
+	rslt.pool = (*C.OCISPool)(ociHandleAlloc((unsafe.Pointer)(env.env), htypeSessionPool))
 
-	err := ociAttrSet((unsafe.Pointer)(rslt.pool), htypeSessionPool, (unsafe.Pointer)(&timeout), 0, attrSessPoolTimeout, gerr)
-	if err != nil {
-		return nil, processError(err)
+	ociErr := ociAttrSetUB4((unsafe.Pointer)(rslt.pool), htypeSessionPool, durationSeconds(cfg.Timeout), attrSessPoolTimeout, rslt.err)
+	if ociErr != nil {
+		rslt.free()
+		return nil, processError(ociErr)
 	}
 
-	err = checkError(
+	if rslt.usesDRCP() {
+		if err := rslt.setPoolAuth(); err != nil {
+			rslt.free()
+			return nil, processError(err)
+		}
+	}
+
+	// statement caching is always on; the pool is homogeneous unless asked otherwise
+	var mode C.ub4 = C.OCI_SPC_STMTCACHE
+	if !cfg.Heterogeneous && !cfg.ExternalAuth {
+		mode |= C.OCI_SPC_HOMOGENEOUS
+	}
+	if !cfg.Events {
+		mode |= C.OCI_SPC_NO_RLB
+	}
+
+	// with external authentication there are no credentials to pass
+	database, databaseLen := oraText(rslt.database)
+	username, usernameLen := oraText(rslt.username)
+	password, passwordLen := oraText(rslt.password)
+
+	ociErr = checkError(
 		C.OCISessionPoolCreate(
-			genv, gerr, rslt.pool,
+			env.env, rslt.err, rslt.pool,
 			&rslt.poolName, &rslt.poolNameLen,
-			(*C.OraText)(&rslt.database[0]), (C.ub4)(len(rslt.database)),
-			(C.ub4)(minSessions), (C.ub4)(maxSessions), (C.ub4)(incrStep),
-			(*C.OraText)(&rslt.username[0]), (C.ub4)(len(rslt.username)),
-			(*C.OraText)(&rslt.password[0]), (C.ub4)(len(rslt.password)),
-			// homogenous and statement caching
-			C.OCI_SPC_HOMOGENEOUS+C.OCI_SPC_STMTCACHE), gerr)
+			database, databaseLen,
+			(C.ub4)(cfg.MinSessions), (C.ub4)(cfg.MaxSessions), (C.ub4)(cfg.Increment),
+			username, usernameLen,
+			password, passwordLen,
+			mode), rslt.err)
+
+	// OCI has its own copy of the credentials now
+	zeroBytes(rslt.password)
+	rslt.password = nil
+	rslt.cfg.Password = ""
+
+	warning, e := loginError(ociErr)
+	if e != nil {
+		rslt.free()
+		return nil, e
+	}
+	rslt.warning = warning
 
-	return rslt, processError(err)
+	if e := rslt.configure(); e != nil {
+		rslt.Destroy()
+		return nil, e
+	}
+
+	return rslt, nil
 
 }
 
+// Warning returns the warning, if any, the pool user got when the pool was created: a
+// *PasswordExpiringWarning if the password is about to expire.
+func (pool *Pool) Warning() error {
+	return pool.warning
+}
+
+// oraText returns b as an OCI text pointer and length, or nil and zero if b is empty.
+func oraText(b []byte) (*C.OraText, C.ub4) {
+	if len(b) == 0 {
+		return nil, 0
+	}
+	return (*C.OraText)(&b[0]), C.ub4(len(b))
+}
+
+// configure applies the settings from cfg that can only be set once the pool exists.
+func (pool *Pool) configure() error {
+
+	cfg := &pool.cfg
+
+	err := ociAttrSetUB1((unsafe.Pointer)(pool.pool), htypeSessionPool, uint8(cfg.GetMode), attrSessPoolGetMode, pool.err)
+	if err != nil {
+		return processError(err)
+	}
+
+	if cfg.MaxLifetime > 0 {
+		err = ociAttrSetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, durationSeconds(cfg.MaxLifetime), attrSessPoolMaxLifetime, pool.err)
+		if err != nil {
+			return processError(err)
+		}
+	}
+
+	if cfg.WaitTimeout > 0 {
+		err = ociAttrSetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, durationMillis(cfg.WaitTimeout), attrSessPoolWaitTimeout, pool.err)
+		if err != nil {
+			return processError(err)
+		}
+	}
+
+	if cfg.StatementCacheSize > 0 {
+		err = ociAttrSetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, cfg.StatementCacheSize, attrSessPoolStmtCacheSize, pool.err)
+		if err != nil {
+			return processError(err)
+		}
+	}
+
+	return nil
+}
+
+// free releases the handles of a pool that was never successfully created.
+func (pool *Pool) free() {
+	ociHandleFree((unsafe.Pointer)(pool.pool), htypeSessionPool)
+	ociHandleFree((unsafe.Pointer)(pool.err), htypeError)
+	if pool.auth != nil {
+		ociHandleFree((unsafe.Pointer)(pool.auth), htypeAuthInfo)
+	}
+	pool.pool = nil
+	pool.err = nil
+	pool.auth = nil
+}
+
+// durationSeconds converts d to the whole seconds OCI expects, rounding up so
+// that a small positive duration doesn't turn into "no timeout".
+func durationSeconds(d time.Duration) uint32 {
+	return uint32((d + time.Second - 1) / time.Second)
+}
+
+// durationMillis is durationSeconds for the attributes OCI takes in milliseconds.
+func durationMillis(d time.Duration) uint32 {
+	return uint32((d + time.Millisecond - 1) / time.Millisecond)
+}
+
 // Destroy shuts down all connections to the pool.
 func (pool *Pool) Destroy() {
 	err := checkError(C.OCISessionPoolDestroy(pool.pool, pool.err, C.OCI_SPD_FORCE), pool.err)
 	ociHandleFree((unsafe.Pointer)(pool.pool), htypeSessionPool)
+	if pool.auth != nil {
+		ociHandleFree((unsafe.Pointer)(pool.auth), htypeAuthInfo)
+		pool.auth = nil
+	}
 	if err != nil {
 		panic(err)
 	}
 }
 
+// SetConnectionTimeout sets how long an idle session may stay in the pool before it is closed.
 func (pool *Pool) SetConnectionTimeout(duration time.Duration) {
-	//OCI_ATTR_SPOOL_TIMEOUT
+	maybePanic(ociAttrSetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, durationSeconds(duration), attrSessPoolTimeout, pool.err))
+	pool.cfg.Timeout = duration
 }
 
+// GetConnectionTimeout returns how long an idle session may stay in the pool.
 func (pool *Pool) GetConnectionTimeout() time.Duration {
-	//OCI_ATTR_SPOOL_TIMEOUT
-	return 0
+	v, err := ociAttrGetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolTimeout, pool.err)
+	maybePanic(err)
+	return time.Duration(v) * time.Second
 }
 
+// AcquireMode determines what Acquire does when every session in the pool is busy.
 type AcquireMode C.ub1
 
 const (
@@ -109,56 +263,274 @@ const (
 	AcquireModeForce  AcquireMode = C.OCI_SPOOL_ATTRVAL_FORCEGET
 )
 
+// SetAcquireNoWait changes the AcquireMode of the pool.
 func (pool *Pool) SetAcquireNoWait(value AcquireMode) {
-	//OCI_ATTR_SPOOL_GETMODE
+	maybePanic(ociAttrSetUB1((unsafe.Pointer)(pool.pool), htypeSessionPool, uint8(value), attrSessPoolGetMode, pool.err))
+	pool.cfg.GetMode = value
 }
 
+// GetAcquireMode returns the current AcquireMode of the pool.
 func (pool *Pool) GetAcquireMode() AcquireMode {
-	//OCI_ATTR_SPOOL_GETMODE
-	return AcquireModeWait
+	v, err := ociAttrGetUB1((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolGetMode, pool.err)
+	maybePanic(err)
+	return AcquireMode(v)
 }
 
+// GetNumBusyConnections returns the number of sessions currently handed out by the pool.
 func (pool *Pool) GetNumBusyConnections() int32 {
-	//OCI_ATTR_SPOOL_BUSY_COUNT
-	return 0
+	v, err := ociAttrGetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolBusyCount, pool.err)
+	maybePanic(err)
+	return int32(v)
 }
 
+// GetNumOpenConnections returns the number of sessions currently open in the pool.
 func (pool *Pool) GetNumOpenConnections() int32 {
-	//OCI_ATTR_SPOOL_OPEN_COUNT
-	return 0
+	v, err := ociAttrGetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolOpenCount, pool.err)
+	maybePanic(err)
+	return int32(v)
+}
+
+// Stats returns a snapshot of the pool: session counts from OCI combined with
+// the acquire/release counters kept by this package.
+func (pool *Pool) Stats() (PoolStats, error) {
+
+	var rslt PoolStats
+
+	open, err := ociAttrGetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolOpenCount, pool.err)
+	if err != nil {
+		return rslt, processError(err)
+	}
+
+	busy, err := ociAttrGetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolBusyCount, pool.err)
+	if err != nil {
+		return rslt, processError(err)
+	}
+
+	rslt.Open = open
+	rslt.Busy = busy
+	pool.counters.snapshot(&rslt)
+
+	return rslt, nil
+}
+
+// Ping acquires a session and makes a round trip to the database with it. It is intended
+// for health checks; the session is released again before Ping returns.
+func (pool *Pool) Ping(ctx context.Context) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sess, err := pool.acquireOrGiveUp(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = sess.Ping(ctx)
+
+	if e := sess.Release(); err == nil {
+		err = e
+	}
+
+	return err
+}
+
+// acquireOrGiveUp acquires a session, but returns ctx.Err() if ctx is done first. OCI
+// can't interrupt the wait for a session, so a session that arrives after that is
+// released again.
+func (pool *Pool) acquireOrGiveUp(ctx context.Context) (*Session, error) {
+
+	type acquired struct {
+		sess *Session
+		err  error
+	}
+
+	ch := make(chan acquired, 1)
+	go func() {
+		sess, err := pool.AcquireContext(ctx)
+		ch <- acquired{sess, err}
+	}()
+
+	select {
+	case a := <-ch:
+		return a.sess, a.err
+	case <-ctx.Done():
+		go func() {
+			if a := <-ch; a.sess != nil {
+				a.sess.Release()
+			}
+		}()
+		return nil, ctx.Err()
+	}
 }
 
+// SetStatementCacheSize changes the number of statements cached by each session of the
+// pool, see PoolConfig.StatementCacheSize. It panics if OCI refuses the size.
 func (pool *Pool) SetStatementCacheSize(value uint32) {
-	//OCI_ATTR_SPOOL_STMTCACHESIZE
+	maybePanic(ociAttrSetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, value, attrSessPoolStmtCacheSize, pool.err))
+	pool.cfg.StatementCacheSize = value
+}
+
+// GetStatementCacheSize returns the number of statements cached by each session of the pool.
+func (pool *Pool) GetStatementCacheSize() uint32 {
+	v, err := ociAttrGetUB4((unsafe.Pointer)(pool.pool), htypeSessionPool, attrSessPoolStmtCacheSize, pool.err)
+	maybePanic(err)
+	return v
 }
 
 type Session struct {
-	svc *C.OCISvcCtx  // service context handle (associates connection with session)
-	err *C.OCIError   // session error handle
-	ses *C.OCISession // session handle - used for date/time/number functions
+	svc  *C.OCISvcCtx  // service context handle (associates connection with session)
+	err  *C.OCIError   // session error handle
+	ses  *C.OCISession // session handle - used for date/time/number functions
+	env  *Environment  // the environment the session was created in
+	pool *Pool         // the pool this session came from, nil for standalone sessions
+	srv  *C.OCIServer  // server handle of a standalone session
+	obs  Observer      // copied from the pool configuration
+	trc  Tracer        // copied from the pool configuration
+	ctx  context.Context
+
+	warning error // login warning, see Warning
+
+	traced bool // tracing attributes have been set and need clearing on release
+
+	tag   string // session tag, see AcquireTagged
+	retag bool   // tag differs from the one the pool knows, and must be set on release
+
+	// statement cache hits and misses, see StatementCacheStats; atomic, as they may be
+	// read while another goroutine prepares a statement
+	stmtHits   atomic.Uint64
+	stmtMisses atomic.Uint64
+
+	stmts map[*stmtHandles]struct{} // of statements not yet released, released with the session
+
+	// statements garbage collected without being released, left by their finalizer for
+	// the next call on the session to release, as the finalizer may not wait for the guard
+	orphanMu sync.Mutex
+	orphans  []*stmtHandles
+
+	guard guard // serialises calls into OCI, see enter
+
+	attached bool // standalone session: server attached
+	begun    bool // standalone session: session begun
 }
 
+// OCISessionGet error codes meaning no session became free in time
+const (
+	oraSessGetTimeout     = 24457
+	oraSessGetWaitTimeout = 24496
+)
+
 // Acquire gets a session from the pool in order to execute SQL against the database.
+// If the password of the session user is about to expire, Session.Warning returns a
+// *PasswordExpiringWarning.
 func (pool *Pool) Acquire() (*Session, error) {
+	return pool.AcquireContext(context.Background())
+}
 
-	rslt := &Session{}
-	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(genv), htypeError))
+// AcquireContext is like Acquire, but ctx becomes the parent of the spans created for the
+// session when the pool has a Tracer. The trace id is passed to the database as the
+// CLIENT_IDENTIFIER of the session so that database side diagnostics can be correlated
+// with the trace.
+func (pool *Pool) AcquireContext(ctx context.Context) (*Session, error) {
+	sess, _, err := pool.acquire(ctx, acquireParams{})
+	return sess, err
+}
+
+// AcquireTagged gets a session that was released with the given tag (see ReleaseWithTag),
+// so that session state such as NLS settings can be reused instead of set up again.
+// If no such session is free, a new or untagged session is returned; with matchAny, a
+// session with a different tag may be returned as well. The boolean result reports
+// whether the session has the requested tag.
+//
+// When the tag doesn't match and the pool has a TagFixup function, it is called to bring
+// the session into the requested state; the session then counts as tagged with tag, and
+// Release keeps that tag.
+func (pool *Pool) AcquireTagged(tag string, matchAny bool) (*Session, bool, error) {
+	return pool.acquire(context.Background(), acquireParams{tag: tag, matchAny: matchAny})
+}
+
+// acquireParams describes the session asked for by the various Acquire functions.
+type acquireParams struct {
+	tag      string // wanted session tag, "" for no preference
+	matchAny bool   // accept a session with another tag if none with tag is free
+
+	auth  *C.OCIAuthInfo // credentials for a heterogeneous pool, nil for the pool user
+	proxy bool           // auth names a user to proxy as, through the pool user
+}
+
+func (pool *Pool) acquire(ctx context.Context, params acquireParams) (rslt *Session, found bool, e error) {
+
+	_, span := startSpan(pool.cfg.Tracer, ctx, spanAcquire, nil)
+	defer func() {
+		if e == nil && rslt.warning != nil {
+			finishSpan(span, rslt.warning)
+			return
+		}
+		finishSpan(span, e)
+	}()
+
+	rslt = &Session{env: pool.env, pool: pool, obs: pool.cfg.Observer, trc: pool.cfg.Tracer, ctx: ctx}
+	rslt.guard.strict = pool.cfg.CheckConcurrency
+	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(pool.env.env), htypeError))
+
+	var mode C.ub4 = C.OCI_SESSGET_SPOOL
+	var tag *C.OraText
+	var tagLen C.ub4
+	var retTag *C.OraText
+	var retTagLen C.ub4
+	var cfound C.boolean
+
+	switch {
+	case params.proxy:
+		mode |= C.OCI_SESSGET_CREDPROXY
+	case params.auth == nil && pool.cfg.ExternalAuth:
+		mode |= C.OCI_SESSGET_CREDEXT
+	}
+
+	if pool.cfg.DRCP {
+		if pool.cfg.Purity == PurityNew {
+			mode |= C.OCI_SESSGET_PURITY_NEW
+		} else {
+			mode |= C.OCI_SESSGET_PURITY_SELF
+		}
+	}
+
+	if params.tag != "" {
+		tagBytes := []byte(params.tag)
+		tag = (*C.OraText)(&tagBytes[0])
+		tagLen = C.ub4(len(tagBytes))
+		if params.matchAny {
+			mode |= C.OCI_SESSGET_SPOOL_MATCHANY
+		}
+	}
+
+	start := time.Now()
 
 	// get the session (which actually returns the service handle, not the session... )
 	err := checkError(
 		C.OCISessionGet(
-			genv, rslt.err, &rslt.svc,
-			nil, pool.poolName, pool.poolNameLen,
-			nil, 0, nil, nil, nil, C.OCI_SESSGET_SPOOL), rslt.err)
+			pool.env.env, rslt.err, &rslt.svc,
+			params.auth, pool.poolName, pool.poolNameLen,
+			tag, tagLen, &retTag, &retTagLen, &cfound, mode), rslt.err)
 
 	if err != nil {
 		if err.IsError() {
-			return nil, err.err
+			pool.counters.failed(err.code == oraSessGetTimeout || err.code == oraSessGetWaitTimeout)
+			ociHandleFree((unsafe.Pointer)(rslt.err), htypeError)
+			return nil, false, err.err
 		}
 
-		fmt.Println(err.Warning())
 	}
 
+	wait := time.Since(start)
+
+	rslt.warning, _ = loginError(err)
+
+	if retTag != nil && retTagLen > 0 {
+		rslt.tag = C.GoStringN((*C.char)(unsafe.Pointer(retTag)), C.int(retTagLen))
+	}
+	found = params.tag != "" && cfound != 0
+
 	// now actually get the session handle (I know, right?)
 	ses, err := ociAttrGetPointer(
 		(unsafe.Pointer)(rslt.svc),
@@ -168,12 +540,277 @@ func (pool *Pool) Acquire() (*Session, error) {
 
 	rslt.ses = (*C.OCISession)(ses)
 
-	return rslt, processError(err)
+	if err != nil {
+		pool.counters.failed(false)
+		rslt.drop()
+		return nil, false, processError(err)
+	}
+
+	if pool.cfg.OnNewSession != nil {
+		if e := rslt.initNewSession(pool.cfg.OnNewSession); e != nil {
+			pool.counters.failed(false)
+			rslt.drop()
+			return nil, false, fmt.Errorf("new session init: %v", e)
+		}
+	}
 
+	if params.tag != "" && !found && pool.cfg.TagFixup != nil {
+		if e := pool.cfg.TagFixup(rslt, params.tag, rslt.tag); e != nil {
+			pool.counters.failed(false)
+			rslt.drop()
+			return nil, false, fmt.Errorf("session tag fixup: %v", e)
+		}
+		rslt.tag = params.tag
+		rslt.retag = true
+		found = true
+	}
+
+	if e := rslt.propagateTrace(ctx); e != nil {
+		pool.counters.failed(false)
+		rslt.drop()
+		return nil, false, e
+	}
+
+	// only now that the session is handed out does it count as acquired
+	pool.counters.acquired(wait)
+
+	return rslt, found, nil
+
+}
+
+// Warning returns the warning, if any, the session got when it logged in: a
+// *PasswordExpiringWarning if the password is about to expire.
+func (sess *Session) Warning() error {
+	return sess.warning
+}
+
+// initNewSession calls init if the physical session behind sess hasn't been through it yet.
+// Sessions are marked with an OCI context value of session duration, so the mark disappears
+// with the database session itself, and sessions the pool reopens are initialized again.
+func (sess *Session) initNewSession(init SessionInitFunc) error {
+
+	var value unsafe.Pointer
+
+	err := checkError(
+		C.OCIContextGetValue(
+			(unsafe.Pointer)(sess.ses), sess.err,
+			&C.ocigoInitKey[0], C.ocigoInitKeyLen, &value), sess.err)
+
+	if e := processError(err); e != nil {
+		return e
+	}
+
+	if value != nil {
+		return nil
+	}
+
+	if e := init(sess); e != nil {
+		return e
+	}
+
+	err = checkError(
+		C.OCIContextSetValue(
+			(unsafe.Pointer)(sess.ses), sess.err, C.OCI_DURATION_SESSION,
+			&C.ocigoInitKey[0], C.ocigoInitKeyLen, (unsafe.Pointer)(&C.ocigoInitDone)), sess.err)
+
+	return processError(err)
+}
+
+// Tag returns the tag of the session: the tag it was acquired with, or the one it was
+// fixed up to by the pool's TagFixup function.
+func (sess *Session) Tag() string {
+	return sess.tag
+}
+
+// enter takes the session's guard for a call into OCI, and returns ErrSessionClosed once
+// the session has been released or closed. If it returns nil, call exit when done.
+func (sess *Session) enter() error {
+	if err := sess.guard.enter(); err != nil {
+		return err
+	}
+	if sess.svc == nil {
+		sess.guard.exit()
+		return ErrSessionClosed
+	}
+	sess.releaseOrphans()
+	return nil
+}
+
+func (sess *Session) exit() {
+	sess.guard.exit()
+}
+
+// closeStatements releases the statements the user didn't, so that they can't be used
+// with a session that belongs to somebody else, or to nobody.
+func (sess *Session) closeStatements() {
+
+	if sess.guard.enter() != nil {
+		return
+	}
+	defer sess.guard.exit()
+
+	for stmt := range sess.stmts {
+		stmt.release(true)
+	}
+	sess.stmts = nil
+
+	sess.orphanMu.Lock()
+	sess.orphans = nil
+	sess.orphanMu.Unlock()
+}
+
+// orphan hands the handles of a statement that was garbage collected without being
+// released to the session, which releases them on its next call.
+func (sess *Session) orphan(stmt *stmtHandles) {
+	sess.orphanMu.Lock()
+	sess.orphans = append(sess.orphans, stmt)
+	sess.orphanMu.Unlock()
+}
+
+// releaseOrphans releases the statements handed over by orphan. The caller holds the guard.
+func (sess *Session) releaseOrphans() {
+
+	sess.orphanMu.Lock()
+	orphans := sess.orphans
+	sess.orphans = nil
+	sess.orphanMu.Unlock()
+
+	for _, stmt := range orphans {
+		// not if the session released it already
+		if _, ok := sess.stmts[stmt]; ok {
+			delete(sess.stmts, stmt)
+			stmt.release(false)
+		}
+	}
+}
+
+// Environment returns the environment the session was created in.
+func (sess *Session) Environment() *Environment {
+	return sess.env
+}
+
+// StatementCacheSize returns the number of statements the session caches.
+func (sess *Session) StatementCacheSize() uint32 {
+	if err := sess.enter(); err != nil {
+		panic(err)
+	}
+	defer sess.exit()
+
+	v, err := ociAttrGetUB4((unsafe.Pointer)(sess.svc), htypeSvcCtx, attrStmtcachesize, sess.err)
+	maybePanic(err)
+	return v
+}
+
+// StatementCacheStats returns how many Prepare calls on the session found their statement
+// in the statement cache, and how many didn't. Pool.Stats has the totals for a pool.
+func (sess *Session) StatementCacheStats() (hits, misses uint64) {
+	return sess.stmtHits.Load(), sess.stmtMisses.Load()
+}
+
+func (sess *Session) statementCacheHit() {
+	sess.stmtHits.Add(1)
+	if sess.pool != nil {
+		sess.pool.counters.statementCache(true)
+	}
+}
+
+func (sess *Session) statementCacheMiss() {
+	sess.stmtMisses.Add(1)
+	if sess.pool != nil {
+		sess.pool.counters.statementCache(false)
+	}
+}
+
+// SetContext replaces the context that spans for this session are created under,
+// and passes the new trace id to the database, as AcquireContext does.
+func (sess *Session) SetContext(ctx context.Context) error {
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	sess.ctx = ctx
+	sess.exit()
+
+	return sess.propagateTrace(ctx)
+}
+
+// propagateTrace sets the CLIENT_IDENTIFIER of the session to the trace id of its context.
+func (sess *Session) propagateTrace(ctx context.Context) error {
+
+	if sess.trc == nil {
+		return nil
+	}
+
+	traceID := sess.trc.TraceID(ctx)
+	if traceID == "" {
+		return nil
+	}
+
+	return sess.SetClientIdentifier(traceID)
+}
+
+// Ping makes a round trip to the database to check the session is still usable.
+// If ctx is done before the database answers, the call is interrupted and ctx.Err() returned.
+func (sess *Session) Ping(ctx context.Context) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	stop := sess.breakOnDone(ctx)
+	err := checkError(C.OCIPing(sess.svc, sess.err, C.OCI_DEFAULT), sess.err)
+
+	if stop() {
+		return ctx.Err()
+	}
+
+	return processError(err)
+}
+
+// breakOnDone interrupts whatever OCI call is running on the session if ctx is done
+// before the returned function is called. That function reports whether a break happened.
+func (sess *Session) breakOnDone(ctx context.Context) func() bool {
+
+	if ctx.Done() == nil {
+		return func() bool { return false }
+	}
+
+	done := make(chan struct{})
+	broke := make(chan bool, 1)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			// a separate error handle, as the session's one belongs to the interrupted call
+			errh := (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(sess.env.env), htypeError))
+			C.OCIBreak(unsafe.Pointer(sess.svc), errh)
+			ociHandleFree((unsafe.Pointer)(errh), htypeError)
+			broke <- true
+		case <-done:
+			broke <- false
+		}
+	}()
+
+	return func() bool {
+		close(done)
+		if <-broke {
+			C.OCIReset(unsafe.Pointer(sess.svc), sess.err)
+			return true
+		}
+		return false
+	}
 }
 
 // Commit issues a commit to the database.
 func (sess *Session) Commit() error {
+	if e := sess.enter(); e != nil {
+		return e
+	}
+	defer sess.exit()
 	err := checkError(
 		C.OCITransCommit(
 			sess.svc,
@@ -185,6 +822,10 @@ func (sess *Session) Commit() error {
 
 // Rollback issues a rollback to the database.
 func (sess *Session) Rollback() error {
+	if e := sess.enter(); e != nil {
+		return e
+	}
+	defer sess.exit()
 	err := checkError(
 		C.OCITransRollback(
 			sess.svc,
@@ -205,8 +846,14 @@ const (
 )
 
 // StartTransaction allows you to manually start a transaction in one of the TxnType modes.
+// Begin offers transactions with savepoints, a name and a timeout.
 func (sess *Session) StartTransaction(txType TxnType) error {
 
+	if e := sess.enter(); e != nil {
+		return e
+	}
+	defer sess.exit()
+
 	err := checkError(
 		C.OCITransStart(
 			sess.svc,
@@ -218,51 +865,303 @@ func (sess *Session) StartTransaction(txType TxnType) error {
 
 }
 
-// Release puts the session back in the pool for reuse.
+// Release puts the session back in the pool for reuse. Before that, depending on the pool
+// configuration, uncommitted work is rolled back and the session is reset (see
+// PoolConfig.RollbackOnRelease and PoolConfig.ResetSession); the tracing attributes are
+// always cleared. If any of that fails the session is dropped from the pool instead.
+// A tagged session (see AcquireTagged) keeps its tag, so ResetSession is not called for
+// it; use ReleaseWithTag with an empty tag to reset it and remove the tag.
+//
+// Releasing a standalone session (see Connect) closes it.
 func (sess *Session) Release() error {
+	if sess.pool == nil {
+		return sess.Close()
+	}
+	return sess.release(sess.tag, sess.retag)
+}
+
+// ReleaseWithTag puts the session back in the pool, tagged with tag, so that a later
+// AcquireTagged with the same tag can reuse the session state. The pool's ResetSession
+// function is not called, as that would undo the state the tag describes. An empty tag
+// removes the tag from the session.
+func (sess *Session) ReleaseWithTag(tag string) error {
+	if sess.pool == nil {
+		return errors.New("ReleaseWithTag: not a pooled session")
+	}
+	return sess.release(tag, true)
+}
+
+func (sess *Session) release(tag string, retag bool) error {
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	sess.exit()
+
+	// the pool keeps handing a tagged session out as being in the state its tag
+	// describes, so only an untagged session may be reset
+	resetErr := sess.reset(tag == "")
+
+	// after the reset, which may prepare statements of its own
+	sess.closeStatements()
+
+	var mode C.ub4 = C.OCI_DEFAULT
+	var ctag *C.OraText
+	var ctagLen C.ub4
+
+	switch {
+	case resetErr != nil:
+		mode = C.OCI_SESSRLS_DROPSESS
+	case retag:
+		mode = C.OCI_SESSRLS_RETAG
+		if tag != "" {
+			tagBytes := []byte(tag)
+			ctag = (*C.OraText)(&tagBytes[0])
+			ctagLen = C.ub4(len(tagBytes))
+		}
+	}
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
 
 	err := checkError(
-		C.OCISessionRelease(sess.svc, sess.err, nil, 0, C.OCI_DEFAULT), sess.err)
+		C.OCISessionRelease(sess.svc, sess.err, ctag, ctagLen, mode), sess.err)
+
+	if sess.pool != nil {
+		sess.pool.counters.released()
+	}
 
 	sess.svc = nil
 	sess.err = nil
 	sess.ses = nil
 
-	return processError(err)
+	if e := processError(err); e != nil {
+		return e
+	}
+
+	return resetErr
 
 }
 
-func (sess *Session) SetClientIdentifier(value string) {
+// drop releases the session and tells the pool to close it rather than reuse it.
+func (sess *Session) drop() {
+	sess.closeStatements()
+	checkError(C.OCISessionRelease(sess.svc, sess.err, nil, 0, C.OCI_SESSRLS_DROPSESS), sess.err)
+	ociHandleFree((unsafe.Pointer)(sess.err), htypeError)
+	sess.svc = nil
+	sess.err = nil
+	sess.ses = nil
+}
 
-	maybePanic(ociAttrSetString(unsafe.Pointer(sess.ses), htypeSession, value, attrClientIdentifier, sess.err))
+// reset undoes whatever state the current user of a pooled session left behind.
+// The ResetSession function of the pool is only called when callResetFunc is set.
+func (sess *Session) reset(callResetFunc bool) error {
 
+	if sess.pool != nil && sess.pool.cfg.RollbackOnRelease {
+		inTxn, err := sess.inTransaction()
+		if err != nil {
+			return err
+		}
+		if inTxn {
+			if e := sess.Rollback(); e != nil {
+				return e
+			}
+		}
+	}
+
+	// don't let the next user of the session inherit our tracing attributes
+	if err := sess.clearTraceContext(); err != nil {
+		return err
+	}
+
+	if callResetFunc && sess.pool != nil && sess.pool.cfg.ResetSession != nil {
+		if err := sess.pool.cfg.ResetSession(sess); err != nil {
+			return fmt.Errorf("session reset: %v", err)
+		}
+	}
+
+	return nil
 }
 
 func (sess *Session) SetCurrentSchema(value string) {
+	if err := sess.enter(); err != nil {
+		panic(err)
+	}
+	defer sess.exit()
+
 	maybePanic(ociAttrSetString(unsafe.Pointer(sess.ses), htypeSession, value, attrCurrentSchema, sess.err))
 }
 
 func (sess *Session) GetCurrentSchema() string {
 
+	if err := sess.enter(); err != nil {
+		panic(err)
+	}
+	defer sess.exit()
+
 	result, err := ociAttrGetString(sess.ses, htypeSession, attrCurrentSchema, sess.err)
 	maybePanic(err)
 
 	return result
 }
 
-func (sess *Session) SetModule(value string) {
-	//OCI_ATTR_MODULE
-  maybePanic(ociAttrSetString(unsafe.Pointer(sess.ses), htypeSession, value, attrses))
+// maximum lengths, in bytes, of the end-to-end tracing attributes
+const (
+	maxModuleLen           = 48
+	maxActionLen           = 32
+	maxClientInfoLen       = 64
+	maxClientIdentifierLen = 64
+	maxDBOpLen             = 30
+)
+
+// TraceContext holds the end-to-end tracing attributes of a session. They show up in
+// V$SESSION, AWR and SQL monitoring reports. An empty field clears the attribute.
+type TraceContext struct {
+	Module           string // V$SESSION.MODULE, up to 48 bytes
+	Action           string // V$SESSION.ACTION, up to 32 bytes
+	ClientInfo       string // V$SESSION.CLIENT_INFO, up to 64 bytes
+	ClientIdentifier string // V$SESSION.CLIENT_IDENTIFIER, up to 64 bytes
+	DBOp             string // database operation name for real-time SQL monitoring, up to 30 bytes
+}
+
+// checkTraceAttr returns an error if value is too long for the tracing attribute name.
+func checkTraceAttr(name string, value string, maxLen int) error {
+	if len(value) > maxLen {
+		return fmt.Errorf("%s may be at most %d bytes, %q is %d", name, maxLen, value, len(value))
+	}
+	return nil
+}
+
+// setTraceAttr validates and sets one of the end-to-end tracing attributes. The values are
+// sent to the database with the next round trip.
+func (sess *Session) setTraceAttr(name string, value string, maxLen int, attr ociAttrType) error {
+
+	if err := checkTraceAttr(name, value, maxLen); err != nil {
+		return err
+	}
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	return processError(sess.setTraceAttrLocked(value, attr))
+}
+
+// setTraceAttrLocked sets a tracing attribute that has been validated. The caller holds
+// the guard.
+func (sess *Session) setTraceAttrLocked(value string, attr ociAttrType) *OciError {
+
+	err := ociAttrSetString(unsafe.Pointer(sess.ses), htypeSession, value, attr, sess.err)
+	if err == nil {
+		sess.traced = true
+	}
+
+	return err
+}
+
+// SetModule sets the MODULE of the session.
+func (sess *Session) SetModule(value string) error {
+	return sess.setTraceAttr("module", value, maxModuleLen, attrModule)
 }
 
-func (sess *Session) SetAction(value string) {
-	//OCI_ATTR_ACTION
+// SetAction sets the ACTION of the session.
+func (sess *Session) SetAction(value string) error {
+	return sess.setTraceAttr("action", value, maxActionLen, attrAction)
+}
+
+// SetClientInfo sets the CLIENT_INFO of the session.
+func (sess *Session) SetClientInfo(value string) error {
+	return sess.setTraceAttr("client info", value, maxClientInfoLen, attrClientInfo)
+}
 
+// SetClientIdentifier sets the CLIENT_IDENTIFIER of the session.
+func (sess *Session) SetClientIdentifier(value string) error {
+	return sess.setTraceAttr("client identifier", value, maxClientIdentifierLen, attrClientIdentifier)
 }
 
-func (sess *Session) SetClientInfo(value string) {
-	//OCI_ATTR_CLIENT_INFO up to 64 bytes
+// SetDBOp sets the database operation name used by real-time SQL monitoring.
+func (sess *Session) SetDBOp(value string) error {
+	return sess.setTraceAttr("database operation", value, maxDBOpLen, attrDBOp)
+}
 
+// traceAttr is one of the end-to-end tracing attributes, with the value to set it to.
+type traceAttr struct {
+	name   string
+	value  string
+	maxLen int
+	attr   ociAttrType
+}
+
+func traceAttrs(tc TraceContext) []traceAttr {
+	return []traceAttr{
+		{"module", tc.Module, maxModuleLen, attrModule},
+		{"action", tc.Action, maxActionLen, attrAction},
+		{"client info", tc.ClientInfo, maxClientInfoLen, attrClientInfo},
+		{"client identifier", tc.ClientIdentifier, maxClientIdentifierLen, attrClientIdentifier},
+		{"database operation", tc.DBOp, maxDBOpLen, attrDBOp},
+	}
+}
+
+// SetTraceContext sets all of the end-to-end tracing attributes at once. Every value is
+// validated before anything is set. Attributes left empty in tc are cleared. Should OCI
+// refuse one of the values, all of the attributes are cleared rather than left half set.
+func (sess *Session) SetTraceContext(tc TraceContext) error {
+
+	attrs := traceAttrs(tc)
+
+	for _, a := range attrs {
+		if err := checkTraceAttr(a.name, a.value, a.maxLen); err != nil {
+			return err
+		}
+	}
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	return sess.setTraceContextLocked(attrs)
+}
+
+// setTraceContextLocked sets attrs, which have been validated. The caller holds the guard.
+func (sess *Session) setTraceContextLocked(attrs []traceAttr) error {
+
+	for _, a := range attrs {
+		if err := processError(sess.setTraceAttrLocked(a.value, a.attr)); err != nil {
+			// don't leave a mix of old and new values behind
+			for _, c := range attrs {
+				sess.setTraceAttrLocked("", c.attr)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearTraceContext resets the tracing attributes, if any were set, before the session
+// goes back to the pool.
+func (sess *Session) clearTraceContext() error {
+
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	if !sess.traced {
+		return nil
+	}
+
+	if err := sess.setTraceContextLocked(traceAttrs(TraceContext{})); err != nil {
+		return err
+	}
+
+	sess.traced = false
+
+	return nil
 }
 
 func (sess *Session) SetLobPrefetchSize(value uint32) {
@@ -314,21 +1213,26 @@ func (sess *Session) GetState() SessionState {
 	return Stateful
 }
 
+// IsTransactionInProgress reports whether the session has uncommitted work.
 func (sess *Session) IsTransactionInProgress() bool {
-	//OCI_ATTR_TRANSACTION_IN_PROGRESS
-	return false
+	rslt, err := sess.inTransaction()
+	if err != nil {
+		panic(err)
+	}
+	return rslt
 }
 
-// package initialization - there is only one environment for whoever uses this library.
-
-func init() {
-
-	errcode := C.OCIEnvCreate(&genv, C.OCI_THREADED+C.OCI_OBJECT+C.OCI_EVENTS, nil, nil, nil, nil, 0, nil)
-
-	if errcode != 0 {
-		panic(fmt.Errorf("OCIEnvCreate failed with errcode = %d.\n", errcode))
+// inTransaction is isTransactionInProgress for callers that don't hold the guard.
+func (sess *Session) inTransaction() (bool, error) {
+	if err := sess.enter(); err != nil {
+		return false, err
 	}
+	defer sess.exit()
 
-	gerr = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(genv), htypeError))
+	rslt, err := sess.isTransactionInProgress()
+	return rslt, processError(err)
+}
 
+func (sess *Session) isTransactionInProgress() (bool, *OciError) {
+	return ociAttrGetBoolean(unsafe.Pointer(sess.ses), htypeSession, attrTransactionInProgress, sess.err)
 }
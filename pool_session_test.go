@@ -0,0 +1,100 @@
+package oci
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDurationRounding(t *testing.T) {
+
+	tests := []struct {
+		in            time.Duration
+		seconds, msec uint32
+	}{
+		{0, 0, 0},
+		{time.Nanosecond, 1, 1},
+		{999 * time.Microsecond, 1, 1},
+		{time.Millisecond, 1, 1},
+		{1500 * time.Microsecond, 1, 2},
+		{time.Second, 1, 1000},
+		{1500 * time.Millisecond, 2, 1500},
+	}
+
+	for _, tt := range tests {
+		if got := durationSeconds(tt.in); got != tt.seconds {
+			t.Errorf("durationSeconds(%v) = %d, want %d", tt.in, got, tt.seconds)
+		}
+		if got := durationMillis(tt.in); got != tt.msec {
+			t.Errorf("durationMillis(%v) = %d, want %d", tt.in, got, tt.msec)
+		}
+	}
+}
+
+func TestCheckTraceAttr(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		maxLen int
+	}{
+		{"module", maxModuleLen},
+		{"action", maxActionLen},
+		{"client info", maxClientInfoLen},
+		{"client identifier", maxClientIdentifierLen},
+		{"database operation", maxDBOpLen},
+	}
+
+	for _, tt := range tests {
+		for _, n := range []int{0, 1, tt.maxLen} {
+			if err := checkTraceAttr(tt.name, strings.Repeat("x", n), tt.maxLen); err != nil {
+				t.Errorf("%s of %d bytes: %v", tt.name, n, err)
+			}
+		}
+		if err := checkTraceAttr(tt.name, strings.Repeat("x", tt.maxLen+1), tt.maxLen); err == nil {
+			t.Errorf("%s of %d bytes: no error", tt.name, tt.maxLen+1)
+		}
+	}
+
+	// the limits are in bytes, not characters
+	if err := checkTraceAttr("action", strings.Repeat("é", maxActionLen/2+1), maxActionLen); err == nil {
+		t.Errorf("action of %d bytes: no error", maxActionLen+2)
+	}
+}
+
+func TestAttrString(t *testing.T) {
+
+	tests := []struct {
+		in   string
+		want []byte
+	}{
+		{"", []byte{0}},
+		{"a", []byte{'a', 0}},
+		{"module", []byte{'m', 'o', 'd', 'u', 'l', 'e', 0}},
+	}
+
+	for _, tt := range tests {
+		if got := attrString(tt.in); !bytes.Equal(got, tt.want) {
+			t.Errorf("attrString(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSetTraceContextValidatesFirst(t *testing.T) {
+
+	sess := &Session{} // released: no service context
+
+	// a value that is too long is refused before the session is looked at
+	tc := TraceContext{Module: "billing", DBOp: strings.Repeat("x", maxDBOpLen+1)}
+	if err := sess.SetTraceContext(tc); err == nil || err == ErrSessionClosed {
+		t.Errorf("SetTraceContext(%+v) = %v, want a length error", tc, err)
+	}
+
+	tc.DBOp = "nightly"
+	if err := sess.SetTraceContext(tc); err != ErrSessionClosed {
+		t.Errorf("SetTraceContext(%+v) = %v, want ErrSessionClosed", tc, err)
+	}
+	if sess.traced {
+		t.Error("traced set on a released session")
+	}
+}
@@ -0,0 +1,130 @@
+package oci
+
+import (
+	"sync"
+	"time"
+)
+
+// AcquireWaitBuckets are the upper bounds of the buckets in PoolStats.WaitHistogram.
+// The histogram has one more bucket than this slice, for waits longer than the last bound.
+// A pool copies the bounds when it is created; changing them only affects later pools.
+var AcquireWaitBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// PoolStats is a point in time snapshot of a pool, as returned by Pool.Stats.
+type PoolStats struct {
+	Open uint32 // sessions currently open, as reported by OCI
+	Busy uint32 // sessions currently handed out, as reported by OCI
+
+	Acquires uint64 // successful calls to Acquire
+	Releases uint64 // sessions given back to the pool
+	Timeouts uint64 // Acquire calls that gave up waiting for a free session
+	Failures uint64 // Acquire calls that failed for any other reason
+
+	WaitTotal     time.Duration   // total time spent in successful Acquire calls
+	WaitHistogram []uint64        // successful Acquire calls by wait time
+	WaitBuckets   []time.Duration // upper bounds of the WaitHistogram buckets but the last
+
+	StmtCacheHits   uint64 // Prepare calls that found the statement in the statement cache
+	StmtCacheMisses uint64 // Prepare calls that had to parse the statement
+}
+
+// poolCounters is the Go side instrumentation of a pool. OCI knows how many sessions
+// are open and busy, but not how long anybody waited for one.
+type poolCounters struct {
+	mu        sync.Mutex
+	acquires  uint64
+	releases  uint64
+	timeouts  uint64
+	failures  uint64
+	waitTotal time.Duration
+	waits     []uint64
+	buckets   []time.Duration // AcquireWaitBuckets, as they were when the pool was created
+
+	stmtHits   uint64
+	stmtMisses uint64
+}
+
+// init copies AcquireWaitBuckets, so that the histogram keeps its shape if they change.
+func (c *poolCounters) init() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.initLocked()
+}
+
+func (c *poolCounters) initLocked() {
+	if c.waits == nil {
+		c.buckets = append([]time.Duration(nil), AcquireWaitBuckets...)
+		c.waits = make([]uint64, len(c.buckets)+1)
+	}
+}
+
+func (c *poolCounters) acquired(wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.initLocked()
+
+	c.acquires++
+	c.waitTotal += wait
+
+	i := 0
+	for i < len(c.buckets) && wait > c.buckets[i] {
+		i++
+	}
+	c.waits[i]++
+}
+
+func (c *poolCounters) failed(timeout bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timeout {
+		c.timeouts++
+	} else {
+		c.failures++
+	}
+}
+
+func (c *poolCounters) released() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.releases++
+}
+
+func (c *poolCounters) statementCache(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if hit {
+		c.stmtHits++
+	} else {
+		c.stmtMisses++
+	}
+}
+
+// snapshot copies the counters into s.
+func (c *poolCounters) snapshot(s *PoolStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s.Acquires = c.acquires
+	s.Releases = c.releases
+	s.Timeouts = c.timeouts
+	s.Failures = c.failures
+	s.WaitTotal = c.waitTotal
+	s.StmtCacheHits = c.stmtHits
+	s.StmtCacheMisses = c.stmtMisses
+	c.initLocked()
+	s.WaitHistogram = append([]uint64(nil), c.waits...)
+	s.WaitBuckets = c.buckets
+}
@@ -12,12 +12,13 @@ import (
 )
 
 type Raw struct {
+	env *Environment // the environment the raw was allocated in
 	data *C.OCIRaw
 	dataptr unsafe.Pointer
 }
 
 func rawFinalizer(r *Raw)  {
-	err := checkError(C.OCIRawResize(genv, gerr, 0, (**C.OCIRaw)(unsafe.Pointer(r.dataptr))), gerr)
+	err := checkError(C.OCIRawResize(r.env.env, r.env.err, 0, (**C.OCIRaw)(unsafe.Pointer(r.dataptr))), r.env.err)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -25,7 +26,7 @@ func rawFinalizer(r *Raw)  {
 
 func MakeRaw() *Raw {
 	var d *C.OCIRaw
-	rslt := &Raw{}
+	rslt := &Raw{env: mustDefaultEnvironment()}
 	rslt.data = d
 	rslt.dataptr = unsafe.Pointer(&d)
 	runtime.SetFinalizer(rslt, rawFinalizer)
@@ -33,23 +34,29 @@ func MakeRaw() *Raw {
 }
 
 func MakeRawWithSize(size int) *Raw {
+	return makeRawIn(mustDefaultEnvironment(), size)
+}
+
+// makeRawIn allocates a raw of size bytes in env, such as the environment of the session
+// it is fetched with.
+func makeRawIn(env *Environment, size int) *Raw {
 
 	var d *C.OCIRaw
 
-	err := checkError(C.OCIRawResize(genv, gerr, C.uint(size), (**C.OCIRaw)(unsafe.Pointer(&d))), gerr)
+	err := checkError(C.OCIRawResize(env.env, env.err, C.uint(size), (**C.OCIRaw)(unsafe.Pointer(&d))), env.err)
 	if err != nil {
 		panic(err.Error())
 	}
 
-	rslt := &Raw{}
+	rslt := &Raw{env: env}
 	rslt.data = d
 	rslt.dataptr = unsafe.Pointer(&d)
 	return rslt
 }
 
 func (r *Raw) Data() []byte {
-	sz := int(C.OCIRawSize(genv, r.data))
-	pt := unsafe.Pointer(C.OCIRawPtr(genv, r.data))
+	sz := int(C.OCIRawSize(r.env.env, r.data))
+	pt := unsafe.Pointer(C.OCIRawPtr(r.env.env, r.data))
 	return C.GoBytes(pt, C.int(sz))
 }
 
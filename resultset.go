@@ -28,6 +28,7 @@ type Column struct {
 	sizeBytes     int32
 	sizeChars     uint16
 	charSemantics tCharSemantics
+	charsetForm   uint8 // SQLCS_IMPLICIT, or SQLCS_NCHAR for national character set columns
 	precision     int16
 	scale         int8
 	nullable      bool
@@ -36,8 +37,15 @@ type Column struct {
 	defnptr       *C.OCIDefine
 	buffer        interface{}
 	ind           *int16
+	rcode         *uint16 // column level return code of the last fetch
 }
 
+const (
+	oraNoDataFound     = 1403
+	oraValueTruncated  = 1406
+	oraFetchIncomplete = 24345 // a truncation or null fetch error occurred
+)
+
 func charSemantics(c tCharSemantics) string {
 	switch c {
 	case charSemanticsByte:
@@ -60,23 +68,71 @@ func (rs *ResultSet) GetColumns() []*Column {
 
 func (rs *ResultSet) Fetch() (rslt bool, err *OciError) {
 
+	stmt := rs.stmt
+	if e := stmt.enter(); e != nil {
+		return false, &OciError{err: e}
+	}
+	defer stmt.exit()
+	if stmt.fetchSpan == nil {
+		_, stmt.fetchSpan = startSpan(stmt.ses.trc, stmt.ses.ctx, spanFetch, stmt.qry)
+		stmt.fetchSpan.SetAttribute(attrKeySQLKey, stmt.Key())
+	}
+
 	err = checkError(
 		C.OCIStmtFetch2(
 			rs.stmt.stm,
 			rs.stmt.err,
 			1, C.OCI_FETCH_NEXT, 0, C.OCI_DEFAULT), rs.stmt.err)
 
-	rslt = err == nil
+	switch {
+	case err == nil:
+		rslt = true
+	case err.code == oraNoDataFound:
+		err = nil
+	case err.code == oraValueTruncated || err.code == oraFetchIncomplete:
+		err = rs.truncated(err)
+		rslt = err == nil
+	default:
+		rslt = !err.IsError()
+	}
 
-	if !rslt {
-		if err.code == 1403 {
-			err = nil
-		}
+	if rslt {
+		stmt.fetched++
+	} else {
+		stmt.endFetch(err.asError())
 	}
 
 	return
 }
 
+// truncated turns the error of a fetch that truncated a column into one wrapping
+// ErrTruncated. The row is fine if no column was truncated (a null fetch warning).
+func (rs *ResultSet) truncated(err *OciError) *OciError {
+	for _, column := range rs.columns {
+		if column.rcode != nil && *column.rcode == oraValueTruncated {
+			return &OciError{code: oraValueTruncated, err: fmt.Errorf("%w: column %s", ErrTruncated, column.name)}
+		}
+	}
+	if err.IsError() {
+		return err
+	}
+	return nil
+}
+
+// endFetch reports the rows fetched from a result set to the observer and finishes the
+// span covering the fetch, if a fetch is in progress.
+func (stmt *stmtHandles) endFetch(err error) {
+	if stmt.fetchSpan != nil {
+		if stmt.obs != nil {
+			stmt.obs.Fetched(string(stmt.key), stmt.fetched, err)
+		}
+		stmt.fetchSpan.SetAttribute(attrKeyRowsFetched, stmt.fetched)
+		finishSpan(stmt.fetchSpan, err)
+		stmt.fetchSpan = nil
+		stmt.fetched = 0
+	}
+}
+
 func (col *Column) Print() string {
 	return fmt.Sprintf("Name: %v ~ Type: %v ~ SizeBytes: %v ~ SizeChars: %v ~ Char/Byte: %v ~ Prec: %v ~ Scale: %v ~ Nullable: %v ~ ObjSchema: %v ~ ObjName: %v",
 		col.name, SqlTypeName(col.datatype), col.sizeBytes, col.sizeChars, charSemantics(col.charSemantics), col.precision, col.scale, col.nullable, col.nTypeSchema, col.nTypeName)
@@ -99,6 +155,8 @@ func (col *Column) Get() interface{} {
 	switch v := col.buffer.(type) {
 	case []byte:
 		return nulTerminatedByteToString(v)
+	case nstring:
+		return v.String()
 	case *Number:
 		return v
 	case *TimeStamp:
@@ -116,12 +174,24 @@ func (col *Column) Get() interface{} {
 	}
 }
 
-func (stmt *Statement) query(count uint32) (*ResultSet, error) {
+func (stmt *Statement) query(count uint32) (rs *ResultSet, e error) {
+
+	if err := stmt.enter(); err != nil {
+		return nil, err
+	}
+	defer stmt.exit()
 
 	if stmt.stmtype != StmtSelect {
 		return nil, errors.New("statement type must be a query")
 	}
 
+	// a new query restarts the fetch
+	stmt.endFetch(nil)
+
+	_, span := startSpan(stmt.ses.trc, stmt.ses.ctx, spanQuery, stmt.qry)
+	span.SetAttribute(attrKeySQLKey, stmt.Key())
+	defer func() { finishSpan(span, e) }()
+
 	err := stmt.exec(count, false)
 	if err != nil {
 		return nil, processError(err)
@@ -168,24 +238,75 @@ func (stmt *Statement) query(count uint32) (*ResultSet, error) {
 	return rslt, nil
 }
 
+// DefaultLOBFetchSize is the number of bytes of a CLOB or NCLOB value Fetch returns,
+// unless the statement was prepared WithLOBFetchSize.
+const DefaultLOBFetchSize = 64 << 10
+
+// maxLOBFetchSize keeps the define buffer of a LOB column, terminator included, within an sb4.
+const maxLOBFetchSize = 1<<31 - 1 - 2
+
+// maxNCharWidth is the most bytes a character takes in a national character set.
+const maxNCharWidth = 4
+
+// textDefineSize returns the size of the define buffer for a character column, including
+// room for the NUL terminator, which is two bytes in UTF-16.
+func (stmt *Statement) textDefineSize(column *Column, utf16 bool) int32 {
+	switch {
+	case column.datatype == sqltCLOB && utf16:
+		// whole UTF-16 code units
+		return stmt.lobSize&^1 + 2
+	case column.datatype == sqltCLOB:
+		return stmt.lobSize + 1
+	case utf16:
+		// UTF-16 takes up to 4 bytes per character
+		return int32(column.sizeChars)*4 + 2
+	case column.charsetForm == C.SQLCS_NCHAR:
+		return charDefineSize(column, maxNCharWidth)
+	default:
+		return charDefineSize(column, stmt.ses.env.charWidth)
+	}
+}
+
+// charDefineSize returns the size of the define buffer for a character column: room
+// for its length in characters at width bytes each, plus the NUL terminator. The length
+// in bytes the server reports is for the database character set, not the client's.
+func charDefineSize(column *Column, width int32) int32 {
+	chars := int32(column.sizeChars)
+	if column.charSemantics == charSemanticsByte || chars == 0 {
+		// at most one character per byte
+		chars = column.sizeBytes
+	}
+	return chars*width + 1
+}
+
 func (stmt *Statement) doDefine(column *Column, colIndx uint32) (err *OciError) {
 
 	var sqlType ociSqlType
 	var sizeBytes int32
+	var nchar bool
 	var buffer interface{}
 	var bufptr unsafe.Pointer
 
 	switch column.datatype {
-	case sqltVarchar, sqltVarchar2, sqltChar :
-		sizeBytes = column.sizeBytes + 1
-		buf := make([]byte, sizeBytes)
-		buffer = buf
-		bufptr = unsafe.Pointer(&buf[0])
+	case sqltVarchar, sqltVarchar2, sqltChar, sqltCLOB:
+		// CLOB and NCLOB are fetched through the LOB data interface, as text
+		nchar = column.charsetForm == C.SQLCS_NCHAR
+		utf16 := nchar && stmt.ses.env.nationalUTF16()
+		sizeBytes = stmt.textDefineSize(column, utf16)
+		if utf16 {
+			buf := make(nstring, sizeBytes)
+			buffer = buf
+			bufptr = unsafe.Pointer(&buf[0])
+		} else {
+			buf := make([]byte, sizeBytes)
+			buffer = buf
+			bufptr = unsafe.Pointer(&buf[0])
+		}
 		sqlType = C.SQLT_STR
 
 	case sqltNumber:
 		sizeBytes = column.sizeBytes
-		num := makeNumberInstance()
+		num := makeNumberIn(stmt.ses.env)
 		buffer = num
 		bufptr = unsafe.Pointer(&num.number)
 		sqlType = C.SQLT_VNU
@@ -240,7 +361,7 @@ func (stmt *Statement) doDefine(column *Column, colIndx uint32) (err *OciError)
 	case sqltUnsigned8 /* aka RAW */:
 		sizeBytes = column.sizeBytes
 
-		raw := MakeRawWithSize(int(sizeBytes))
+		raw := makeRawIn(stmt.ses.env, int(sizeBytes))
 
 		buffer = raw
 		bufptr = unsafe.Pointer(raw.data)
@@ -253,6 +374,7 @@ func (stmt *Statement) doDefine(column *Column, colIndx uint32) (err *OciError)
 
 	var pdefnptr *C.OCIDefine
 	var pind int16
+	var prcode uint16
 
 	if sqlType != 0 {
 		fmt.Println("defining " + column.name)
@@ -265,11 +387,16 @@ func (stmt *Statement) doDefine(column *Column, colIndx uint32) (err *OciError)
 			C.sb4(sizeBytes),
 			C.ub2(sqlType),
 			unsafe.Pointer(&pind),
-			nil, nil, C.OCI_DEFAULT), stmt.err)
+			nil, (*C.ub2)(unsafe.Pointer(&prcode)), C.OCI_DEFAULT), stmt.err)
+
+		if err == nil && nchar {
+			err = ociAttrSetUB1(unsafe.Pointer(pdefnptr), htypeDefine, C.SQLCS_NCHAR, attrCharsetForm, stmt.err)
+		}
 
 		column.buffer = buffer
 		column.defnptr = pdefnptr
 		column.ind = &pind
+		column.rcode = &prcode
 		fmt.Println("defined column " + column.name)
 	}
 
@@ -303,6 +430,19 @@ func getColumnInfo(paramPtr *C.OCIParam, errhndl *C.OCIError) (rslt *Column, err
 	}
 	rslt.datatype = ociSqlType(ub2)
 
+	// charset form (C.ub1) - tells NCHAR and NVARCHAR2 from CHAR and VARCHAR2
+	switch rslt.datatype {
+	case sqltVarchar, sqltVarchar2, sqltChar, sqltCLOB:
+		rslt.charsetForm, err = ociAttrGetUB1(
+			(unsafe.Pointer)(paramPtr),
+			(ociHandleType)(dtypeParam),
+			attrCharsetForm, errhndl)
+
+		if err != nil {
+			return
+		}
+	}
+
 	// name (C.OraText *)
 	rslt.name, err = ociAttrGetString(
 		(unsafe.Pointer)(paramPtr),
@@ -0,0 +1,84 @@
+package oci
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCharDefineSize(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		column Column
+		width  int32
+		want   int32
+	}{
+		{"char semantics, AL32UTF8", Column{sizeBytes: 400, sizeChars: 100, charSemantics: charSemanticsChar}, 4, 401},
+		{"char semantics, single byte", Column{sizeBytes: 100, sizeChars: 100, charSemantics: charSemanticsChar}, 1, 101},
+		{"byte semantics, AL32UTF8", Column{sizeBytes: 100, sizeChars: 100, charSemantics: charSemanticsByte}, 4, 401},
+		{"byte semantics, no char size", Column{sizeBytes: 30, charSemantics: charSemanticsByte}, 3, 91},
+	}
+
+	for _, tt := range tests {
+		if got := charDefineSize(&tt.column, tt.width); got != tt.want {
+			t.Errorf("%s: charDefineSize() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+
+	ok, truncated := uint16(0), uint16(oraValueTruncated)
+	rs := &ResultSet{columns: []*Column{
+		{name: "ID", rcode: &ok},
+		{name: "NAME", rcode: &truncated},
+	}}
+
+	err := rs.truncated(&OciError{code: oraFetchIncomplete, err: &OraError{Code: oraFetchIncomplete}})
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("truncated() = %v, want ErrTruncated", err)
+	}
+	if !strings.Contains(err.Error(), "NAME") {
+		t.Errorf("truncated() = %q, want the column named", err.Error())
+	}
+
+	// a null fetch warning with no column truncated leaves the row fine
+	truncated = 0
+	if err := rs.truncated(&OciError{code: oraFetchIncomplete, inf: "ORA-24345"}); err != nil {
+		t.Errorf("truncated() = %v, want nil", err)
+	}
+}
+
+func TestOciErrorUnwrap(t *testing.T) {
+	var err error = &OciError{err: ErrSessionClosed}
+	if !errors.Is(err, ErrSessionClosed) {
+		t.Errorf("errors.Is(%v, ErrSessionClosed) = false", err)
+	}
+}
+
+func TestLOBDefineSize(t *testing.T) {
+
+	tests := []struct {
+		size  int
+		utf16 bool
+		want  int32
+	}{
+		{0, false, DefaultLOBFetchSize + 1},
+		{-1, true, DefaultLOBFetchSize + 2},
+		{4000, false, 4001},
+		{4000, true, 4002},
+		{4001, true, 4002},
+		{math.MaxInt32, false, maxLOBFetchSize + 1},
+	}
+
+	for _, tt := range tests {
+		var cfg prepareConfig
+		WithLOBFetchSize(tt.size)(&cfg)
+		stmt := &Statement{lobSize: cfg.lobFetchSize()}
+		if got := stmt.textDefineSize(&Column{datatype: sqltCLOB}, tt.utf16); got != tt.want {
+			t.Errorf("WithLOBFetchSize(%d), utf16 %t: textDefineSize() = %d, want %d", tt.size, tt.utf16, got, tt.want)
+		}
+	}
+}
@@ -11,8 +11,9 @@ import (
 	"encoding/hex"
 	//"errors"
 	//"fmt"
+	"runtime"
+	"time"
 	"unsafe"
-	//"time"
 )
 
 type StmtType uint16
@@ -30,76 +31,212 @@ const (
 )
 
 type Statement struct {
+	*stmtHandles
 	ses     *Session
-	err     *C.OCIError
-	stm     *C.OCIStmt
-	key     []byte
 	qry     []byte
 	stmtype StmtType
+	lobSize int32 // bytes of a CLOB or NCLOB value fetched, see WithLOBFetchSize
+}
+
+// stmtHandles is what a session needs to release a statement the user didn't. The session
+// keeps track of these rather than of the Statement, so that a Statement nobody refers to
+// any more can still be finalized.
+type stmtHandles struct {
+	err *C.OCIError
+	stm *C.OCIStmt
+	key []byte
+
+	fetchSpan Span // open while a result set of the statement is being fetched
+	fetched   int  // rows fetched so far under fetchSpan
+
+	noCache bool     // prepared with NoStatementCache; always removed from the cache on release
+	obs     Observer // of the session, told about fetches when they end
+
+	bindBufs []unsafe.Pointer // C memory holding bound values, freed on release
+}
+
+// oraStmtNotCached is returned by OCIStmtPrepare2 with OCI_PREP2_CACHE_SEARCHONLY when
+// the statement cache has no statement with the given key.
+const oraStmtNotCached = 24431
+
+// isCacheMiss reports whether err is the statement cache telling us it doesn't have
+// the statement we asked for.
+func isCacheMiss(err *OciError) bool {
+	return err != nil && err.code == oraStmtNotCached
+}
+
+// PrepareOption changes how Session.Prepare prepares a statement.
+type PrepareOption func(*prepareConfig)
+
+type prepareConfig struct {
+	noCache bool
+	lobSize int32
+}
+
+// NoStatementCache removes the statement from the statement cache when it is released,
+// rather than leaving it there for the next Prepare of the same text. Use it for one-off
+// statements that would only push useful ones out of the cache. The statement itself may
+// still come from the cache if one with the same text is there already; such a Prepare
+// is not counted by StatementCacheStats.
+func NoStatementCache() PrepareOption {
+	return func(cfg *prepareConfig) {
+		cfg.noCache = true
+	}
+}
+
+// WithLOBFetchSize sets how many bytes of a CLOB or NCLOB column value Fetch returns, in
+// the client character set (UTF-16 for NCLOB by default). Each such column of the
+// statement takes a buffer of this size; a longer value makes Fetch return an error
+// wrapping ErrTruncated. A size of zero or less keeps DefaultLOBFetchSize.
+func WithLOBFetchSize(size int) PrepareOption {
+	return func(cfg *prepareConfig) {
+		switch {
+		case size <= 0:
+			cfg.lobSize = 0
+		case size > maxLOBFetchSize:
+			cfg.lobSize = maxLOBFetchSize
+		default:
+			cfg.lobSize = int32(size)
+		}
+	}
+}
+
+// lobFetchSize returns the LOB fetch size set WithLOBFetchSize, or the default.
+func (cfg prepareConfig) lobFetchSize() int32 {
+	if cfg.lobSize == 0 {
+		return DefaultLOBFetchSize
+	}
+	return cfg.lobSize
 }
 
 func (stmt Statement) StatementType() StmtType {
 	return stmt.stmtype
 }
 
+// Key returns the key identifying the SQL text of the statement: the hex encoded
+// SHA-256 hash of the text, which is also the statement cache key.
+func (stmt *Statement) Key() string {
+	return string(stmt.key)
+}
+
+// stmtFinalizer leaves the release of a statement the user forgot to the session, rather
+// than block the finalizer goroutine behind a call on the session.
 func stmtFinalizer(stmt *Statement) {
-	stmt.Release(false)
+	stmt.ses.orphan(stmt.stmtHandles)
 }
 
-func (sess *Session) Prepare(sql string) (*Statement, error) {
-	rslt := &Statement{ses: sess, qry: []byte(sql)}
+// Prepare prepares sql for execution, reusing a statement from the session's statement
+// cache when there is one for the same text.
+func (sess *Session) Prepare(sql string, opts ...PrepareOption) (*Statement, error) {
+
+	if err := sess.enter(); err != nil {
+		return nil, err
+	}
+	defer sess.exit()
+
+	var cfg prepareConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	_, span := startSpan(sess.trc, sess.ctx, spanPrepare, []byte(sql))
+
+	rslt, err := sess.prepare(sql, cfg)
+	if rslt != nil {
+		span.SetAttribute(attrKeySQLKey, rslt.Key())
+		sess.track(rslt)
+	}
+
+	finishSpan(span, err)
+
+	return rslt, err
+}
+
+func (sess *Session) prepare(sql string, cfg prepareConfig) (*Statement, error) {
+	rslt := &Statement{stmtHandles: &stmtHandles{noCache: cfg.noCache, obs: sess.obs}, ses: sess, qry: []byte(sql), lobSize: cfg.lobFetchSize()}
 
 	// hash the query, turn to slice, output to hex string, convert to []byte
 	hash := sha256.Sum256(rslt.qry)
 	rslt.key = []byte(hex.EncodeToString(hash[:]))
 
-	rslt.err = (*C.OCIError)(ociHandleAlloc(unsafe.Pointer(genv), htypeError))
+	rslt.err = (*C.OCIError)(ociHandleAlloc(unsafe.Pointer(sess.env.env), htypeError))
+
+	if !cfg.noCache {
+		vErr := checkError(
+			C.OCIStmtPrepare2(
+				sess.svc,
+				&rslt.stm,
+				rslt.err,
+				nil, 0,
+				(*C.OraText)(&rslt.key[0]),
+				(C.ub4)(len(rslt.key)),
+				C.OCI_NTV_SYNTAX,
+				C.OCI_PREP2_CACHE_SEARCHONLY), rslt.err)
+
+		if vErr == nil {
+			sess.statementCacheHit()
+			return rslt, rslt.getStmtType()
+		}
+
+		if !isCacheMiss(vErr) {
+			ociHandleFree(unsafe.Pointer(rslt.err), htypeError)
+			return nil, processError(vErr)
+		}
+
+		sess.statementCacheMiss()
+	}
 
+	// it's not in the cache under its key (or we weren't asked to keep it there), so we
+	// need to create it; OCI still reuses a cached statement with the same text
 	vErr := checkError(
 		C.OCIStmtPrepare2(
 			sess.svc,
 			&rslt.stm,
 			rslt.err,
+			(*C.OraText)(&rslt.qry[0]),
+			(C.ub4)(len(rslt.qry)),
 			nil, 0,
-			(*C.OraText)(&rslt.key[0]),
-			(C.ub4)(len(rslt.key)),
 			C.OCI_NTV_SYNTAX,
-			C.OCI_PREP2_CACHE_SEARCHONLY), rslt.err)
+			C.OCI_DEFAULT), rslt.err)
 
-	if vErr == nil {
-		stype, vErr := ociAttrGetUB2(unsafe.Pointer(rslt.stm), htypeStatement, attrStmtType, rslt.err)
-		rslt.stmtype = StmtType(stype)
-		return rslt, vErr
+	if vErr != nil && vErr.IsError() {
+		ociHandleFree(unsafe.Pointer(rslt.err), htypeError)
+		return nil, processError(vErr)
 	}
 
-	if vErr.code == 24431 {
-		// it's not in the cache (and hope to Gods that number never changes), so we need to create it
+	return rslt, rslt.getStmtType()
+}
 
-		vErr = checkError(
-			C.OCIStmtPrepare2(
-				sess.svc,
-				&rslt.stm,
-				rslt.err,
-				(*C.OraText)(&rslt.qry[0]),
-				(C.ub4)(len(rslt.qry)),
-				nil, 0,
-				C.OCI_NTV_SYNTAX,
-				C.OCI_DEFAULT), rslt.err)
+// track registers stmt with the session, which releases it if the user doesn't.
+func (sess *Session) track(stmt *Statement) {
+	if sess.stmts == nil {
+		sess.stmts = make(map[*stmtHandles]struct{})
+	}
+	sess.stmts[stmt.stmtHandles] = struct{}{}
+	runtime.SetFinalizer(stmt, stmtFinalizer)
+}
 
-		if vErr == nil {
-			stype, err := ociAttrGetUB2(
-				unsafe.Pointer(rslt.stm),
-				htypeStatement,
-				attrStmtType,
-				rslt.err)
+// enter takes the guard of the statement's session, like Session.enter, and returns
+// ErrStatementClosed if the statement was released. If it returns nil, call exit when done.
+func (stmt *Statement) enter() error {
+	if err := stmt.ses.enter(); err != nil {
+		return err
+	}
+	if stmt.stm == nil {
+		stmt.ses.exit()
+		return ErrStatementClosed
+	}
+	return nil
+}
 
-			rslt.stmtype = StmtType(stype)
+func (stmt *Statement) exit() {
+	stmt.ses.exit()
+}
 
-			return rslt, processError(err)
-		}
-		return nil, processError(vErr)
-	}
-	return nil, processError(vErr)
+func (stmt *Statement) getStmtType() error {
+	stype, err := ociAttrGetUB2(unsafe.Pointer(stmt.stm), htypeStatement, attrStmtType, stmt.err)
+	stmt.stmtype = StmtType(stype)
+	return processError(err)
 }
 
 func (stmt *Statement) exec(iterations uint32, commit bool) *OciError {
@@ -111,6 +248,8 @@ func (stmt *Statement) exec(iterations uint32, commit bool) *OciError {
 		flags = C.OCI_COMMIT_ON_SUCCESS
 	}
 
+	start := time.Now()
+
 	vErr := checkError(
 		C.OCIStmtExecute(
 			stmt.ses.svc,
@@ -118,16 +257,57 @@ func (stmt *Statement) exec(iterations uint32, commit bool) *OciError {
 			stmt.err,
 			iters, 0, nil, nil, flags), stmt.err)
 
+	if stmt.ses.obs != nil {
+		stmt.ses.obs.Executed(stmt.Key(), time.Since(start), vErr.asError())
+	}
+
 	return vErr
 
 }
 
+// execSQL runs a statement without binds, such as SET TRANSACTION.
+func (sess *Session) execSQL(sql string) error {
+	stmt, err := sess.Prepare(sql)
+	if err != nil {
+		return err
+	}
+	err = stmt.Execute()
+	if e := stmt.Release(true); err == nil {
+		err = e
+	}
+	return err
+}
+
 func (stmt *Statement) Execute() error {
-	return processError(stmt.exec(1, false))
+	return stmt.execute(false)
 }
 
 func (stmt *Statement) ExecuteAndCommit() error {
-	return processError(stmt.exec(1, true))
+	return stmt.execute(true)
+}
+
+// execute runs a non-query statement once, inside a span when tracing
+func (stmt *Statement) execute(commit bool) error {
+
+	if err := stmt.enter(); err != nil {
+		return err
+	}
+	defer stmt.exit()
+
+	_, span := startSpan(stmt.ses.trc, stmt.ses.ctx, spanExecute, stmt.qry)
+	span.SetAttribute(attrKeySQLKey, stmt.Key())
+
+	err := processError(stmt.exec(1, commit))
+
+	if err == nil {
+		if rows, e := ociAttrGetUB4(unsafe.Pointer(stmt.stm), htypeStatement, attrRowCount, stmt.err); e == nil {
+			span.SetAttribute(attrKeyRowsAffected, int64(rows))
+		}
+	}
+
+	finishSpan(span, err)
+
+	return err
 }
 
 func (stmt *Statement) Query() (*ResultSet, error) {
@@ -140,36 +320,55 @@ func (stmt *Statement) QueryLimit(count uint32) (*ResultSet, error) {
 
 func (stmt *Statement) Release(KeepInCache bool) error {
 
+	// a statement of a released session has been released with it
+	if err := stmt.ses.enter(); err != nil {
+		if err == ErrSessionClosed {
+			return nil
+		}
+		return err
+	}
+	defer stmt.ses.exit()
+
 	if stmt.stm != nil {
+		delete(stmt.ses.stmts, stmt.stmtHandles)
+		runtime.SetFinalizer(stmt, nil)
+		return stmt.release(KeepInCache)
+	}
 
-		var mode C.ub4
+	return nil
 
-		if KeepInCache {
-			mode = C.OCI_DEFAULT
-		} else {
-			mode = C.OCI_STRLS_CACHE_DELETE
-		}
+}
 
-		vErr := checkError(
-			C.OCIStmtRelease(
-				stmt.stm,
-				stmt.err,
-				(*C.OraText)(&stmt.key[0]),
-				(C.ub4)(len(stmt.key)),
-				mode), stmt.err)
+// release releases the statement handle, keeping it in the statement cache if keep is
+// set, and frees what goes with it. The caller holds the guard of the session.
+func (h *stmtHandles) release(keep bool) error {
 
-		rslt := processError(vErr)
+	h.endFetch(nil)
 
-		stmt.stm = nil
-		stmt.ses = nil
-		ociHandleFree(unsafe.Pointer(stmt.err), htypeError)
-		stmt.err = nil
+	var mode C.ub4
 
-		return rslt
+	if keep && !h.noCache {
+		mode = C.OCI_DEFAULT
+	} else {
+		mode = C.OCI_STRLS_CACHE_DELETE
 	}
 
-	return nil
+	vErr := checkError(
+		C.OCIStmtRelease(
+			h.stm,
+			h.err,
+			(*C.OraText)(&h.key[0]),
+			(C.ub4)(len(h.key)),
+			mode), h.err)
+
+	rslt := processError(vErr)
+
+	h.stm = nil
+	h.freeBinds()
+	ociHandleFree(unsafe.Pointer(h.err), htypeError)
+	h.err = nil
 
+	return rslt
 }
 
 func (rset *ResultSet) Next() []interface{} {
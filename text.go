@@ -0,0 +1,93 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <stdlib.h>
+#include <string.h>
+#include <oci.h>
+*/
+import "C"
+import (
+	"unicode/utf16"
+	"unsafe"
+)
+
+// nstring is a define buffer for national character set (NCHAR, NVARCHAR2) data, which
+// OCI delivers as NUL terminated UTF-16 in native byte order.
+type nstring []byte
+
+func (s nstring) String() string {
+	if len(s) < 2 {
+		return ""
+	}
+	units := unsafe.Slice((*uint16)(unsafe.Pointer(&s[0])), len(s)/2)
+	n := 0
+	for n < len(units) && units[n] != 0 {
+		n++
+	}
+	return string(utf16.Decode(units[:n]))
+}
+
+// encodeUTF16 encodes s as UTF-16 in native byte order, the way OCI expects NCHAR data.
+func encodeUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	if len(units) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&units[0])), len(units)*2)
+}
+
+// BindString binds value to the placeholder at position (starting at 1).
+func (stmt *Statement) BindString(position uint32, value string) error {
+	return stmt.bindText(position, []byte(value), C.SQLCS_IMPLICIT)
+}
+
+// BindNString binds value to the placeholder at position (starting at 1) as national
+// character set data, for NCHAR and NVARCHAR2 columns. Characters that the database
+// character set can't represent survive only this way.
+func (stmt *Statement) BindNString(position uint32, value string) error {
+	if stmt.ses.env.nationalUTF16() {
+		return stmt.bindText(position, encodeUTF16(value), C.SQLCS_NCHAR)
+	}
+	// the national character set comes from NLS_LANG; let OCI convert
+	return stmt.bindText(position, []byte(value), C.SQLCS_NCHAR)
+}
+
+// bindText binds data, text in the character set of the given form. The data is copied to
+// C memory, as OCI reads it at execute time; it is freed when the statement is released.
+func (stmt *Statement) bindText(position uint32, data []byte, form C.ub1) error {
+
+	if err := stmt.enter(); err != nil {
+		return err
+	}
+	defer stmt.exit()
+
+	buf := C.malloc(C.size_t(len(data) + 1))
+	if len(data) > 0 {
+		C.memcpy(buf, unsafe.Pointer(&data[0]), C.size_t(len(data)))
+	}
+	stmt.bindBufs = append(stmt.bindBufs, buf)
+
+	var bind Bind
+
+	err := checkError(
+		C.OCIBindByPos(
+			stmt.stm, &bind.bindhndl, stmt.err,
+			C.ub4(position),
+			buf, C.sb4(len(data)), C.SQLT_CHR,
+			nil, nil, nil, 0, nil, C.OCI_DEFAULT), stmt.err)
+
+	if err == nil && form != C.SQLCS_IMPLICIT {
+		err = ociAttrSetUB1(unsafe.Pointer(bind.bindhndl), htypeBind, uint8(form), attrCharsetForm, stmt.err)
+	}
+
+	return processError(err)
+}
+
+// freeBinds frees the C memory of the values bound to the statement.
+func (stmt *stmtHandles) freeBinds() {
+	for _, buf := range stmt.bindBufs {
+		C.free(buf)
+	}
+	stmt.bindBufs = nil
+}
@@ -0,0 +1,45 @@
+package oci
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestEncodeUTF16(t *testing.T) {
+
+	if b := encodeUTF16(""); b != nil {
+		t.Errorf(`encodeUTF16("") = %v, want nil`, b)
+	}
+
+	b := encodeUTF16("A€")
+	if len(b) != 4 || binary.NativeEndian.Uint16(b) != 'A' || binary.NativeEndian.Uint16(b[2:]) != 0x20ac {
+		t.Errorf(`encodeUTF16("A€") = %v`, b)
+	}
+
+	// outside the BMP: a surrogate pair
+	if b := encodeUTF16("😀"); len(b) != 4 || binary.NativeEndian.Uint16(b) != 0xd83d {
+		t.Errorf(`encodeUTF16("😀") = %v`, b)
+	}
+}
+
+func TestNStringString(t *testing.T) {
+
+	for _, s := range []string{"", "NCHAR", "Grüße", "日本語", "a😀b"} {
+		buf := make(nstring, 64)
+		copy(buf, encodeUTF16(s))
+		if got := buf.String(); got != s {
+			t.Errorf("nstring.String() = %q, want %q", got, s)
+		}
+	}
+
+	// a value that fills the buffer has no terminator
+	if got := nstring(encodeUTF16("full")).String(); got != "full" {
+		t.Errorf(`nstring.String() = %q, want "full"`, got)
+	}
+
+	for _, buf := range []nstring{nil, {}, {'x'}} {
+		if got := buf.String(); got != "" {
+			t.Errorf("nstring(%v).String() = %q, want empty", []byte(buf), got)
+		}
+	}
+}
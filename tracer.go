@@ -0,0 +1,90 @@
+package oci
+
+import (
+	"context"
+	"errors"
+)
+
+// Tracer creates spans around database calls: Acquire, Prepare, Execute, Query and the
+// fetching of a result set. Spans are children of the context given to Pool.AcquireContext
+// (or Session.SetContext). See the ocitrace package for an OpenTelemetry implementation.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs map[string]interface{}) (context.Context, Span)
+
+	// TraceID returns the hex encoded id of the trace ctx belongs to, or "" if there is none.
+	TraceID(ctx context.Context) string
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttribute(key string, value interface{})
+
+	// AddEvent records something that happened during the operation that is not an error,
+	// such as a login warning.
+	AddEvent(name string, attrs map[string]interface{})
+
+	// End records err, if any, and finishes the span.
+	End(err error)
+}
+
+// span and attribute names used by this package
+const (
+	spanAcquire = "oci.Acquire"
+	spanPrepare = "oci.Prepare"
+	spanExecute = "oci.Execute"
+	spanQuery   = "oci.Query"
+	spanFetch   = "oci.Fetch"
+
+	eventWarning = "oci.Warning"
+
+	attrKeyDBSystem     = "db.system"
+	attrKeyDBStatement  = "db.statement"
+	attrKeyRowsAffected = "db.rows_affected"
+	attrKeyRowsFetched  = "db.oracle.rows_fetched"
+	attrKeySQLKey       = "db.oracle.sql_key"
+	attrKeyErrorCode    = "db.oracle.error_code"
+	attrKeyMessage      = "message"
+)
+
+// WithTracer registers a Tracer for the pool and every session acquired from it.
+func WithTracer(tracer Tracer) PoolOption {
+	return func(cfg *PoolConfig) {
+		cfg.Tracer = tracer
+	}
+}
+
+// noopSpan is used when no Tracer is configured, so callers never need a nil check.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{})         {}
+func (noopSpan) AddEvent(name string, attrs map[string]interface{}) {}
+func (noopSpan) End(err error)                                      {}
+
+// startSpan starts a span as a child of ctx with the attributes common to every span.
+func startSpan(tracer Tracer, ctx context.Context, name string, sql []byte) (context.Context, Span) {
+
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+
+	attrs := map[string]interface{}{attrKeyDBSystem: "oracle"}
+	if sql != nil {
+		attrs[attrKeyDBStatement] = string(sql)
+	}
+
+	return tracer.Start(ctx, name, attrs)
+}
+
+// finishSpan ends span, adding the ORA error code of err as an attribute. A warning is
+// recorded as an event, not as an error.
+func finishSpan(span Span, err error) {
+	var warning *PasswordExpiringWarning
+	if errors.As(err, &warning) {
+		span.AddEvent(eventWarning, map[string]interface{}{attrKeyMessage: warning.Message})
+		err = nil
+	}
+	if code := ErrorCode(err); code != 0 {
+		span.SetAttribute(attrKeyErrorCode, code)
+	}
+	span.End(err)
+}
@@ -0,0 +1,252 @@
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IsolationLevel is the isolation level of a transaction started with Begin.
+type IsolationLevel int
+
+const (
+	IsolationDefault       IsolationLevel = iota // the session's, normally read committed
+	IsolationReadCommitted                       // each statement sees data committed before it began
+	IsolationSerializable                        // the transaction sees data committed before it began
+)
+
+// TxOptions configures a transaction started with Begin or InTx.
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool          // a read-only transaction; it can't be combined with an isolation level
+	Name      string        // shown in V$TRANSACTION and in-doubt transaction views
+	Timeout   time.Duration // roll the transaction back if it isn't finished by then; zero is no limit
+}
+
+// setTransaction returns the SET TRANSACTION statement for the options, or "" when the
+// defaults need none.
+func (opts TxOptions) setTransaction() (string, error) {
+
+	var parts []string
+
+	switch opts.Isolation {
+	case IsolationDefault:
+	case IsolationReadCommitted:
+		parts = append(parts, "ISOLATION LEVEL READ COMMITTED")
+	case IsolationSerializable:
+		parts = append(parts, "ISOLATION LEVEL SERIALIZABLE")
+	default:
+		return "", fmt.Errorf("unknown isolation level %d", opts.Isolation)
+	}
+
+	if opts.ReadOnly {
+		if len(parts) > 0 {
+			return "", errors.New("a read-only transaction can't have an isolation level")
+		}
+		parts = append(parts, "READ ONLY")
+	}
+
+	if opts.Name != "" {
+		parts = append(parts, "NAME '"+strings.ReplaceAll(opts.Name, "'", "''")+"'")
+	}
+
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return "SET TRANSACTION " + strings.Join(parts, " "), nil
+}
+
+// Tx is a transaction on a session, started with Session.Begin. Statements run on the
+// session are part of it until Commit or Rollback is called.
+type Tx struct {
+	sess   *Session
+	ctx    context.Context
+	cancel context.CancelFunc // of the timeout, if any
+	stop   chan struct{}      // stops the goroutine watching ctx, if any
+
+	mu   sync.Mutex
+	done bool // Commit or Rollback was called
+}
+
+// Begin starts a transaction on the session. If ctx is done, or opts.Timeout passes,
+// before Commit or Rollback is called, the transaction is rolled back; a call that is
+// running on the session at that time finishes first. Commit and Rollback then roll back
+// whatever ran on the session since, too.
+func (sess *Session) Begin(ctx context.Context, opts TxOptions) (*Tx, error) {
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sql, err := opts.setTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	if sql != "" {
+		if err := sess.execSQL(sql); err != nil {
+			return nil, err
+		}
+	}
+
+	tx := &Tx{sess: sess, ctx: ctx}
+	if opts.Timeout > 0 {
+		tx.ctx, tx.cancel = context.WithTimeout(ctx, opts.Timeout)
+	}
+
+	if done := tx.ctx.Done(); done != nil {
+		tx.stop = make(chan struct{})
+		go tx.watch(done)
+	}
+
+	return tx, nil
+}
+
+// watch rolls the transaction back when its context is done, unless it was finished first.
+func (tx *Tx) watch(done <-chan struct{}) {
+	select {
+	case <-done:
+		// holding mu, so that this can't roll back work after Commit or Rollback
+		tx.mu.Lock()
+		if !tx.done {
+			tx.sess.Rollback()
+		}
+		tx.mu.Unlock()
+	case <-tx.stop:
+	}
+}
+
+// Session returns the session the transaction runs on.
+func (tx *Tx) Session() *Session {
+	return tx.sess
+}
+
+// Commit commits the transaction. If its context is done the session is rolled back
+// instead, and the context's error is returned.
+func (tx *Tx) Commit() error {
+	return tx.finish(true)
+}
+
+// Rollback rolls the transaction back.
+func (tx *Tx) Rollback() error {
+	return tx.finish(false)
+}
+
+// finish ends the transaction; it returns ErrTxDone if that already happened.
+func (tx *Tx) finish(commit bool) error {
+
+	tx.mu.Lock()
+	if tx.done {
+		tx.mu.Unlock()
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.mu.Unlock()
+
+	if tx.stop != nil {
+		close(tx.stop)
+	}
+	if tx.cancel != nil {
+		defer tx.cancel()
+	}
+
+	if err := tx.ctx.Err(); err != nil {
+		// the watcher may have rolled back already, but statements run since then
+		// started a new transaction on the session
+		if e := tx.sess.Rollback(); e != nil || !commit {
+			return e
+		}
+		return err
+	}
+
+	if commit {
+		return tx.sess.Commit()
+	}
+	return tx.sess.Rollback()
+}
+
+// Savepoint marks a point in the transaction that RollbackTo can return to.
+func (tx *Tx) Savepoint(name string) error {
+	return tx.savepointSQL("SAVEPOINT ", name)
+}
+
+// RollbackTo undoes the work of the transaction since the savepoint name was set.
+// The transaction stays open.
+func (tx *Tx) RollbackTo(name string) error {
+	return tx.savepointSQL("ROLLBACK TO SAVEPOINT ", name)
+}
+
+func (tx *Tx) savepointSQL(prefix, name string) error {
+
+	tx.mu.Lock()
+	done := tx.done
+	tx.mu.Unlock()
+	if done {
+		return ErrTxDone
+	}
+	if err := tx.ctx.Err(); err != nil {
+		return err
+	}
+
+	ident, err := quoteIdentifier(name)
+	if err != nil {
+		return err
+	}
+	return tx.sess.execSQL(prefix + ident)
+}
+
+// quoteIdentifier returns name as a quoted Oracle identifier, which is case sensitive.
+func quoteIdentifier(name string) (string, error) {
+	if name == "" || len(name) > 128 || strings.ContainsAny(name, "\"\x00") {
+		return "", fmt.Errorf("invalid identifier %q", name)
+	}
+	return `"` + name + `"`, nil
+}
+
+const (
+	oraCantSerialize = 8177 // can't serialize access for this transaction
+	txAttempts       = 3
+)
+
+// InTx runs fn in a transaction, with opts if given: it commits if fn returns nil and
+// rolls back if fn returns an error or panics. When the transaction fails to serialize
+// (ORA-08177), it is rolled back and fn run again, up to three times in all.
+func (sess *Session) InTx(ctx context.Context, fn func(*Tx) error, opts ...TxOptions) error {
+
+	var o TxOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := sess.inTx(ctx, o, fn)
+		if ErrorCode(err) != oraCantSerialize || attempt == txAttempts || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func (sess *Session) inTx(ctx context.Context, opts TxOptions, fn func(*Tx) error) error {
+
+	tx, err := sess.Begin(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,40 @@
+package oci
+
+import "testing"
+
+func TestSetTransaction(t *testing.T) {
+
+	tests := []struct {
+		opts    TxOptions
+		want    string
+		wantErr bool
+	}{
+		{TxOptions{}, "", false},
+		{TxOptions{Isolation: IsolationSerializable}, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE", false},
+		{TxOptions{Isolation: IsolationReadCommitted, Name: "pay run"}, "SET TRANSACTION ISOLATION LEVEL READ COMMITTED NAME 'pay run'", false},
+		{TxOptions{ReadOnly: true}, "SET TRANSACTION READ ONLY", false},
+		{TxOptions{Name: "it's"}, "SET TRANSACTION NAME 'it''s'", false},
+		{TxOptions{ReadOnly: true, Isolation: IsolationSerializable}, "", true},
+		{TxOptions{Isolation: IsolationLevel(9)}, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.opts.setTransaction()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%+v: setTransaction() = %q, %v; want %q, error %v", tt.opts, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+
+	if got, err := quoteIdentifier("before_update"); err != nil || got != `"before_update"` {
+		t.Errorf(`quoteIdentifier("before_update") = %q, %v`, got, err)
+	}
+
+	for _, name := range []string{"", `a"b`, string(make([]byte, 129))} {
+		if _, err := quoteIdentifier(name); err == nil {
+			t.Errorf("quoteIdentifier(%q) succeeded", name)
+		}
+	}
+}
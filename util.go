@@ -3,6 +3,11 @@ package oci
 /*
 #cgo pkg-config: oci
 #include <oci.h>
+
+// the session pool wait timeout came with Oracle Client 12.2; zero marks it missing
+#ifndef OCI_ATTR_SPOOL_WAIT_TIMEOUT
+#define OCI_ATTR_SPOOL_WAIT_TIMEOUT 0
+#endif
 */
 import "C"
 
@@ -558,6 +563,8 @@ const (
 	attrSessPoolIncr                  ociAttrType = C.OCI_ATTR_SPOOL_INCR                        /* session increment count */
 	attrSessPoolStmtCacheSize         ociAttrType = C.OCI_ATTR_SPOOL_STMTCACHESIZE               /*Stmt cache size of pool  */
 	attrSessPoolAuth                  ociAttrType = C.OCI_ATTR_SPOOL_AUTH                        /* Auth handle on pool handle*/
+	attrSessPoolMaxLifetime           ociAttrType = C.OCI_ATTR_SPOOL_MAX_LIFETIME_SESSION        /* max lifetime of a pooled session */
+	attrSessPoolWaitTimeout           ociAttrType = C.OCI_ATTR_SPOOL_WAIT_TIMEOUT                /* wait timeout for a session get (12.2) */
 	attrDataSize                      ociAttrType = C.OCI_ATTR_DATA_SIZE                         /* maximum size of the data */
 	attrDataType                      ociAttrType = C.OCI_ATTR_DATA_TYPE                         /* the SQL type of the column/argument */
 	attrDispSize                      ociAttrType = C.OCI_ATTR_DISP_SIZE                         /* the display size */
@@ -646,6 +653,10 @@ const (
 	attrAppctxAttr                    ociAttrType = C.OCI_ATTR_APPCTX_ATTR            /* attr  of context to be init*/
 	attrAppctxValue                   ociAttrType = C.OCI_ATTR_APPCTX_VALUE           /* value of context to be init*/
 	attrClientIdentifier              ociAttrType = C.OCI_ATTR_CLIENT_IDENTIFIER      /* value of client id to set*/
+	attrModule                        ociAttrType = C.OCI_ATTR_MODULE                 /* module for tracing */
+	attrAction                        ociAttrType = C.OCI_ATTR_ACTION                 /* action for tracing */
+	attrClientInfo                    ociAttrType = C.OCI_ATTR_CLIENT_INFO            /* client info */
+	attrDBOp                          ociAttrType = C.OCI_ATTR_DBOP                   /* database operation for monitoring */
 	attrIsFinalType                   ociAttrType = C.OCI_ATTR_IS_FINAL_TYPE          /* is final type ? */
 	attrIsInstantiableType            ociAttrType = C.OCI_ATTR_IS_INSTANTIABLE_TYPE   /* is instantiable type ? */
 	attrIsFinalMethod                 ociAttrType = C.OCI_ATTR_IS_FINAL_METHOD        /* is final method ? */
@@ -655,6 +666,7 @@ const (
 	attrCharUsed                      ociAttrType = C.OCI_ATTR_CHAR_USED              /* char length semantics */
 	attrCharSize                      ociAttrType = C.OCI_ATTR_CHAR_SIZE              /* char length */
 	attrSessionState                  ociAttrType = C.OCI_ATTR_SESSION_STATE          /* session state */
+	attrTransactionInProgress         ociAttrType = C.OCI_ATTR_TRANSACTION_IN_PROGRESS /* is a transaction open on the session */
 )
 
 func ociAttrGetString(handle unsafe.Pointer, htype ociHandleType, attrType ociAttrType, errHandle *C.OCIError) (rslt string, err *OciError) {
@@ -727,18 +739,41 @@ func ociAttrGetUB8(handle unsafe.Pointer, htype ociHandleType, attrType ociAttrT
 	return v, checkError(err, errHandle)
 }
 
+// ociAttrGetBoolean reads an attribute of the OCI boolean type, which is an int, not a ub1
+func ociAttrGetBoolean(handle unsafe.Pointer, htype ociHandleType, attrType ociAttrType, errHandle *C.OCIError) (bool, *OciError) {
+	var v C.boolean
+	err := C.OCIAttrGet(handle, (C.ub4)(htype), (unsafe.Pointer)(&v), nil, (C.ub4)(attrType), errHandle)
+	return v != 0, checkError(err, errHandle)
+}
+
 func ociAttrSet(handle unsafe.Pointer, htype ociHandleType, attrPtr unsafe.Pointer, attrSize C.ub4, attrType ociAttrType, errHandle *C.OCIError) *OciError {
 	// fmt.Println("ociAttrSet: ", htype, attrType)
 	return checkError(C.OCIAttrSet(handle, (C.ub4)(htype), attrPtr, attrSize, (C.ub4)(attrType), errHandle), errHandle)
 }
 
+func ociAttrSetUB1(handle unsafe.Pointer, htype ociHandleType, value uint8, attrType ociAttrType, errHandle *C.OCIError) *OciError {
+	v := C.ub1(value)
+	return checkError(C.OCIAttrSet(handle, (C.ub4)(htype), unsafe.Pointer(&v), 0, (C.ub4)(attrType), errHandle), errHandle)
+}
+
+func ociAttrSetUB4(handle unsafe.Pointer, htype ociHandleType, value uint32, attrType ociAttrType, errHandle *C.OCIError) *OciError {
+	v := C.ub4(value)
+	return checkError(C.OCIAttrSet(handle, (C.ub4)(htype), unsafe.Pointer(&v), 0, (C.ub4)(attrType), errHandle), errHandle)
+}
+
+// attrString returns s as a NUL terminated byte slice, so that even an empty string, which
+// clears an attribute, has a valid address to hand to OCI.
+func attrString(s string) []byte {
+	return append([]byte(s), 0)
+}
+
 func ociAttrSetString(handle unsafe.Pointer, htype ociHandleType, strAttr string, attrType ociAttrType, errHandle *C.OCIError) *OciError {
-	b := []byte(strAttr)
-	return checkError(C.OCIAttrSet(handle, (C.ub4)(htype), unsafe.Pointer(&b[0]), C.ub4(len(b)), (C.ub4)(attrType), errHandle), errHandle)
+	b := attrString(strAttr)
+	return checkError(C.OCIAttrSet(handle, (C.ub4)(htype), unsafe.Pointer(&b[0]), C.ub4(len(strAttr)), (C.ub4)(attrType), errHandle), errHandle)
 }
 
 func maybePanic(err *OciError) {
-	if err.err != nil {
+	if err != nil && err.err != nil {
 		panic(err.err)
 	}
 }
@@ -0,0 +1,261 @@
+package oci
+
+/*
+#cgo pkg-config: oci
+#include <string.h>
+#include <oci.h>
+#include <xa.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+)
+
+// XID identifies a global (distributed) transaction branch, as defined by X/Open XA.
+// The transaction manager that coordinates the branches assigns it.
+type XID struct {
+	FormatID            int32
+	GlobalTransactionID []byte // 1 to 64 bytes
+	BranchQualifier     []byte // 1 to 64 bytes
+}
+
+func (xid XID) validate() error {
+	if n := len(xid.GlobalTransactionID); n == 0 || n > C.MAXGTRIDSIZE {
+		return fmt.Errorf("XID global transaction id is %d bytes, must be 1 to %d", n, C.MAXGTRIDSIZE)
+	}
+	if n := len(xid.BranchQualifier); n == 0 || n > C.MAXBQUALSIZE {
+		return fmt.Errorf("XID branch qualifier is %d bytes, must be 1 to %d", n, C.MAXBQUALSIZE)
+	}
+	return nil
+}
+
+// cXID lays xid out the way OCI wants it.
+func (xid XID) cXID() C.XID {
+	var rslt C.XID
+	rslt.formatID = C.long(xid.FormatID)
+	rslt.gtrid_length = C.long(len(xid.GlobalTransactionID))
+	rslt.bqual_length = C.long(len(xid.BranchQualifier))
+	data := unsafe.Pointer(&rslt.data[0])
+	C.memcpy(data, unsafe.Pointer(&xid.GlobalTransactionID[0]), C.size_t(len(xid.GlobalTransactionID)))
+	C.memcpy(unsafe.Add(data, len(xid.GlobalTransactionID)), unsafe.Pointer(&xid.BranchQualifier[0]), C.size_t(len(xid.BranchQualifier)))
+	return rslt
+}
+
+// xidOf is the inverse of cXID.
+func xidOf(c *C.XID) XID {
+	gtridLen, bqualLen := int(c.gtrid_length), int(c.bqual_length)
+	data := C.GoBytes(unsafe.Pointer(&c.data[0]), C.int(gtridLen+bqualLen))
+	return XID{
+		FormatID:            int32(c.formatID),
+		GlobalTransactionID: data[:gtridLen:gtridLen],
+		BranchQualifier:     data[gtridLen:],
+	}
+}
+
+// seconds converts d to the whole seconds OCITransStart takes. Zero stays zero, but
+// anything else under a second is refused rather than turned into zero, which means
+// something else.
+func seconds(d time.Duration) (C.uword, error) {
+	if d < 0 || (d > 0 && d < time.Second) {
+		return 0, fmt.Errorf("transaction timeout %v must be zero or at least a second", d)
+	}
+	return C.uword(d / time.Second), nil
+}
+
+// oraPreparedReadOnly is returned as a warning by OCITransPrepare for a branch that
+// made no changes.
+const oraPreparedReadOnly = 24767
+
+// GlobalTx is a branch of a global transaction on a session, for two-phase commit with
+// other resource managers. Start one with Session.StartGlobal.
+type GlobalTx struct {
+	sess  *Session
+	xid   XID
+	trans *C.OCITrans
+}
+
+// what becomes of the branch after an operation, see call
+type transState int
+
+const (
+	transAttached transState = iota // the branch stays on the session
+	transDetached                   // the session is free for other work
+	transComplete                   // the branch is done; the handle is freed
+)
+
+// StartGlobal starts a branch of the global transaction xid on the session. Once
+// detached, the branch is rolled back if it is not resumed within timeout.
+func (sess *Session) StartGlobal(xid XID, timeout time.Duration) (*GlobalTx, error) {
+
+	secs, err := seconds(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := sess.globalTx(xid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.start(secs, C.OCI_TRANS_NEW); err != nil {
+		tx.free()
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// GlobalTx returns the branch xid of a global transaction that was started elsewhere,
+// such as a prepared branch that a recovering transaction manager commits, rolls back
+// or forgets. Resume it to run statements in it.
+func (sess *Session) GlobalTx(xid XID) (*GlobalTx, error) {
+	return sess.globalTx(xid)
+}
+
+func (sess *Session) globalTx(xid XID) (*GlobalTx, error) {
+
+	if err := xid.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := sess.enter(); err != nil {
+		return nil, err
+	}
+	defer sess.exit()
+
+	tx := &GlobalTx{sess: sess, xid: xid}
+	tx.trans = (*C.OCITrans)(ociHandleAlloc(unsafe.Pointer(sess.env.env), htypeTrans))
+
+	cxid := xid.cXID()
+	err := ociAttrSet(unsafe.Pointer(tx.trans), htypeTrans, unsafe.Pointer(&cxid), C.ub4(unsafe.Sizeof(cxid)), attrXID, sess.err)
+	if err != nil {
+		ociHandleFree(unsafe.Pointer(tx.trans), htypeTrans)
+		return nil, processError(err)
+	}
+
+	return tx, nil
+}
+
+// XID returns the id of the transaction branch.
+func (tx *GlobalTx) XID() XID {
+	return tx.xid
+}
+
+// Detach takes the branch off the session, so that the session can be used for other
+// work and the branch resumed later.
+func (tx *GlobalTx) Detach() error {
+	return tx.call(func(sess *Session) *OciError {
+		return checkError(C.OCITransDetach(sess.svc, sess.err, C.OCI_DEFAULT), sess.err)
+	}, transDetached)
+}
+
+// Resume attaches a detached branch to the session again, waiting up to wait for the
+// branch to become available if it is still in use elsewhere.
+func (tx *GlobalTx) Resume(wait time.Duration) error {
+	secs, err := seconds(wait)
+	if err != nil {
+		return err
+	}
+	return tx.start(secs, C.OCI_TRANS_RESUME)
+}
+
+// Prepare is the first phase of a two-phase commit. readOnly reports that the branch
+// made no changes: it is complete, and must not be committed.
+func (tx *GlobalTx) Prepare() (readOnly bool, e error) {
+	e = tx.call(func(sess *Session) *OciError {
+		err := checkError(C.OCITransPrepare(sess.svc, sess.err, C.OCI_DEFAULT), sess.err)
+		if err != nil && !err.IsError() && err.code == oraPreparedReadOnly {
+			readOnly = true
+			return nil
+		}
+		return err
+	}, transDetached)
+
+	if readOnly {
+		tx.free()
+	}
+	return
+}
+
+// Commit2PC is the second phase of a two-phase commit, for a prepared branch.
+func (tx *GlobalTx) Commit2PC() error {
+	return tx.call(func(sess *Session) *OciError {
+		return checkError(C.OCITransCommit(sess.svc, sess.err, C.OCI_TRANS_TWOPHASE), sess.err)
+	}, transComplete)
+}
+
+// Rollback rolls the branch back, prepared or not.
+func (tx *GlobalTx) Rollback() error {
+	return tx.call(func(sess *Session) *OciError {
+		return checkError(C.OCITransRollback(sess.svc, sess.err, C.OCI_DEFAULT), sess.err)
+	}, transComplete)
+}
+
+// Forget tells the database to forget a branch that was completed heuristically, after
+// the transaction manager has dealt with the outcome.
+func (tx *GlobalTx) Forget() error {
+	return tx.call(func(sess *Session) *OciError {
+		return checkError(C.OCITransForget(sess.svc, sess.err, C.OCI_DEFAULT), sess.err)
+	}, transComplete)
+}
+
+// start starts or resumes the branch on its session.
+func (tx *GlobalTx) start(timeout C.uword, flags C.ub4) error {
+	return tx.call(func(sess *Session) *OciError {
+		return checkError(C.OCITransStart(sess.svc, sess.err, timeout, flags), sess.err)
+	}, transAttached)
+}
+
+// call runs op with the transaction handle set on the session. Unless the branch stays
+// attached, the handle is taken off the session again afterwards, so that the session
+// goes back to its own transactions; once the branch is complete the handle is freed.
+func (tx *GlobalTx) call(op func(sess *Session) *OciError, after transState) error {
+
+	if tx.trans == nil {
+		return ErrTxDone
+	}
+
+	sess := tx.sess
+	if err := sess.enter(); err != nil {
+		return err
+	}
+	defer sess.exit()
+
+	err := ociAttrSet(unsafe.Pointer(sess.svc), htypeSvcCtx, unsafe.Pointer(tx.trans), 0, attrTrans, sess.err)
+	if err == nil {
+		err = op(sess)
+	}
+
+	switch {
+	case after == transComplete && (err == nil || !err.IsError()):
+		tx.freeLocked()
+	case after != transAttached || (err != nil && err.IsError()):
+		ociAttrSet(unsafe.Pointer(sess.svc), htypeSvcCtx, nil, 0, attrTrans, sess.err)
+	}
+
+	return processError(err)
+}
+
+// free releases the transaction handle.
+func (tx *GlobalTx) free() {
+	if tx.sess.guard.enter() != nil {
+		return
+	}
+	defer tx.sess.guard.exit()
+	tx.freeLocked()
+}
+
+// freeLocked is free for callers that hold the session's guard.
+func (tx *GlobalTx) freeLocked() {
+	if tx.trans == nil {
+		return
+	}
+	if sess := tx.sess; sess.svc != nil {
+		ociAttrSet(unsafe.Pointer(sess.svc), htypeSvcCtx, nil, 0, attrTrans, sess.err)
+	}
+	ociHandleFree(unsafe.Pointer(tx.trans), htypeTrans)
+	tx.trans = nil
+}
@@ -0,0 +1,73 @@
+package oci
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestXIDValidate(t *testing.T) {
+
+	gtrid, bqual := []byte("payment-4711"), []byte{1}
+
+	tests := []struct {
+		xid     XID
+		wantErr bool
+	}{
+		{XID{FormatID: 1, GlobalTransactionID: gtrid, BranchQualifier: bqual}, false},
+		{XID{GlobalTransactionID: make([]byte, 64), BranchQualifier: make([]byte, 64)}, false},
+		{XID{BranchQualifier: bqual}, true},
+		{XID{GlobalTransactionID: gtrid}, true},
+		{XID{GlobalTransactionID: make([]byte, 65), BranchQualifier: bqual}, true},
+		{XID{GlobalTransactionID: gtrid, BranchQualifier: make([]byte, 65)}, true},
+	}
+
+	for _, tt := range tests {
+		if err := tt.xid.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: validate() = %v, want error %v", tt.xid, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCXIDRoundTrip(t *testing.T) {
+
+	xids := []XID{
+		{FormatID: 0x4f43, GlobalTransactionID: []byte("payment-4711"), BranchQualifier: []byte{1, 2}},
+		{FormatID: -1, GlobalTransactionID: bytes.Repeat([]byte{0xaa}, 64), BranchQualifier: bytes.Repeat([]byte{0x55}, 64)},
+		{FormatID: 1, GlobalTransactionID: []byte{0}, BranchQualifier: []byte("b")},
+	}
+
+	for _, xid := range xids {
+		c := xid.cXID()
+		got := xidOf(&c)
+		if got.FormatID != xid.FormatID ||
+			!bytes.Equal(got.GlobalTransactionID, xid.GlobalTransactionID) ||
+			!bytes.Equal(got.BranchQualifier, xid.BranchQualifier) {
+			t.Errorf("xidOf(cXID(%+v)) = %+v", xid, got)
+		}
+	}
+}
+
+func TestSeconds(t *testing.T) {
+
+	tests := []struct {
+		in      time.Duration
+		want    uint
+		wantErr bool
+	}{
+		{0, 0, false},
+		{time.Second, 1, false},
+		{90 * time.Second, 90, false},
+		{1500 * time.Millisecond, 1, false},
+		{500 * time.Millisecond, 0, true},
+		{time.Nanosecond, 0, true},
+		{-time.Second, 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := seconds(tt.in)
+		if (err != nil) != tt.wantErr || uint(got) != tt.want {
+			t.Errorf("seconds(%v) = %d, %v; want %d, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
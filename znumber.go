@@ -14,6 +14,7 @@ import (
 
 // Number is an opaque representation of an Oracle Number type. This supports all of the features of Oracle numbers, as they can easily exceed the limitations of most float/int operations.
 type Number struct {
+	env    *Environment // the environment the number was created in
 	err    *C.OCIError
 	number C.OCINumber
 }
@@ -22,9 +23,15 @@ func finalizeNumber(n *Number) {
 	ociHandleFree((unsafe.Pointer)(n.err), htypeError)
 }
 
-func makeNumberInstance() (rslt *Number) {
-	rslt = &Number{}
-	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(genv), htypeError))
+func makeNumberInstance() *Number {
+	return makeNumberIn(mustDefaultEnvironment())
+}
+
+// makeNumberIn returns a Number of env, such as the environment of the session it is
+// fetched with, or of the number it is computed from.
+func makeNumberIn(env *Environment) (rslt *Number) {
+	rslt = &Number{env: env}
+	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(env.env), htypeError))
 	runtime.SetFinalizer(rslt, finalizeNumber)
 	return
 }
@@ -279,7 +286,7 @@ func (num *Number) String() string {
 // Abs returns the absolute value of this Number. The returned number is a new instance.
 func (num *Number) Abs() (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberAbs(
@@ -293,7 +300,7 @@ func (num *Number) Abs() (*Number, error) {
 // Add adds the supplied Number to this Number and returns a new instance.
 func (num *Number) Add(number *Number) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberAdd(
@@ -323,7 +330,7 @@ func (num *Number) Cmp(number *Number) (int, error) {
 // Div divides this Number with the supplied Number and returns a new instance.
 func (num *Number) Div(number *Number) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberDiv(
@@ -338,7 +345,7 @@ func (num *Number) Div(number *Number) (*Number, error) {
 // Mod returns the remainder of a div in a new instance.
 func (num *Number) Mod(number *Number) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberMod(
@@ -353,7 +360,7 @@ func (num *Number) Mod(number *Number) (*Number, error) {
 // Mul returns the product of this Number with the supplied Number in a new instance.
 func (num *Number) Mul(number *Number) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberMul(
@@ -368,7 +375,7 @@ func (num *Number) Mul(number *Number) (*Number, error) {
 // Round returns a new instance with the number of decimal places.
 func (num *Number) Round(decplaces int) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberRound(
@@ -383,7 +390,7 @@ func (num *Number) Round(decplaces int) (*Number, error) {
 // Sub returns a new instance of the supplied Number subtracted from this number.
 func (num *Number) Sub(number *Number) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberSub(
@@ -398,7 +405,7 @@ func (num *Number) Sub(number *Number) (*Number, error) {
 // Trunc truncates a new instance to the number of decimal places.
 func (num *Number) Trunc(decplaces int) (*Number, error) {
 
-	rslt := makeNumberInstance()
+	rslt := makeNumberIn(num.env)
 
 	vErr := checkError(
 		C.OCINumberTrunc(
@@ -73,20 +73,20 @@ func finalizerInterval(iv *Interval) {
 
 func makeTimestampInstance(s *Session, typ TimestampType) (rslt *TimeStamp) {
 	rslt = &TimeStamp{ses: s, tstype: typ}
-	dt := (*C.OCIDateTime)(ociDescriptorAlloc((unsafe.Pointer)(genv), (ociDescriptorType)(typ)))
+	dt := (*C.OCIDateTime)(ociDescriptorAlloc((unsafe.Pointer)(s.env.env), (ociDescriptorType)(typ)))
 	rslt.datetime = dt
 	rslt.ptrdt = unsafe.Pointer(&dt)
-	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(genv), htypeError))
+	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(s.env.env), htypeError))
 	runtime.SetFinalizer(rslt, finalizerTimestamp)
 	return
 }
 
 func makeIntervalInstance(s *Session, typ IntervalType) (rslt *Interval) {
 	rslt = &Interval{ses: s, intype: typ}
-	intvl := (*C.OCIInterval)(ociDescriptorAlloc((unsafe.Pointer)(genv), (ociDescriptorType)(typ)))
+	intvl := (*C.OCIInterval)(ociDescriptorAlloc((unsafe.Pointer)(s.env.env), (ociDescriptorType)(typ)))
 	rslt.interval = intvl
 	rslt.ptrintvl = unsafe.Pointer(&intvl)
-	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(genv), htypeError))
+	rslt.err = (*C.OCIError)(ociHandleAlloc((unsafe.Pointer)(s.env.env), htypeError))
 	runtime.SetFinalizer(rslt, finalizerInterval)
 	return
 }
@@ -651,11 +651,7 @@ func (intvl *Interval) IntervalSubtract(i2 *Interval) (*Interval, error) {
 // ToNumber returns an Oracle Number from an Interval
 func (intvl *Interval) ToNumber() (*Number, error) {
 
-	rslt, e := NumberFromInt(0)
-
-	if e != nil {
-		return rslt, e
-	}
+	rslt := makeNumberIn(intvl.ses.env)
 
 	err := checkError(
 		C.OCIIntervalToNumber(